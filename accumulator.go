@@ -40,6 +40,10 @@ type Accumulator interface {
 		tags map[string]string,
 		t ...time.Time)
 
+	// AddMetric adds a metric to the accumulator, keeping its value type,
+	// such as the metrics of a parser.
+	AddMetric(m Metric)
+
 	SetPrecision(precision, interval time.Duration)
 
 	AddError(err error)
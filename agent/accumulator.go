@@ -98,6 +98,15 @@ func (ac *accumulator) AddHistogram(
 	}
 }
 
+func (ac *accumulator) AddMetric(m telegraf.Metric) {
+	if m == nil {
+		return
+	}
+	if m := ac.maker.MakeMetric(m.Name(), m.Fields(), m.Tags(), m.Type(), ac.getTime([]time.Time{m.Time()})); m != nil {
+		ac.metrics <- m
+	}
+}
+
 // AddError passes a runtime error to the accumulator.
 // The error will be tagged with the plugin name and written to the log.
 func (ac *accumulator) AddError(err error) {
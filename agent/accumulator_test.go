@@ -47,6 +47,33 @@ func TestAddFields(t *testing.T) {
 	require.Equal(t, telegraf.Counter, tp)
 }
 
+func TestAddMetric(t *testing.T) {
+	metrics := make(chan telegraf.Metric, 10)
+	defer close(metrics)
+	a := NewAccumulator(&TestMetricMaker{}, metrics)
+
+	now := time.Now()
+	m, err := metric.New("acctest",
+		map[string]string{"foo": "bar"},
+		map[string]interface{}{"usage": float64(99)},
+		now,
+		telegraf.Gauge,
+	)
+	require.NoError(t, err)
+	a.AddMetric(m)
+
+	testm := <-metrics
+	require.Equal(t, "acctest", testm.Name())
+	require.Equal(t, map[string]string{"foo": "bar"}, testm.Tags())
+	require.Equal(t, map[string]interface{}{"usage": float64(99)}, testm.Fields())
+	require.True(t, now.Equal(testm.Time()))
+	require.Equal(t, telegraf.Gauge, testm.Type())
+
+	// A nil metric, such as of a line not matched by a parser, is ignored.
+	a.AddMetric(nil)
+	require.Len(t, metrics, 0)
+}
+
 func TestAccAddError(t *testing.T) {
 	errBuf := bytes.NewBuffer(nil)
 	log.SetOutput(errBuf)
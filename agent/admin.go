@@ -0,0 +1,459 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+)
+
+// secretRe matches the names of settings holding secrets, which are redacted
+// by the admin API.
+var secretRe = regexp.MustCompile(`(?i)(passw(or)?d|secret|token|credential|api_?key|authorization)`)
+
+const redacted = "<redacted>"
+
+var (
+	durationType         = reflect.TypeOf(time.Duration(0))
+	internalDurationType = reflect.TypeOf(internal.Duration{})
+)
+
+// pluginInfo describes a loaded plugin.
+type pluginInfo struct {
+	Name     string      `json:"name"`
+	Settings interface{} `json:"settings"`
+	Config   interface{} `json:"config"`
+}
+
+// pluginList is the response of the /plugins endpoint.
+type pluginList struct {
+	Inputs      []pluginInfo `json:"inputs"`
+	Processors  []pluginInfo `json:"processors"`
+	Aggregators []pluginInfo `json:"aggregators"`
+	Outputs     []pluginInfo `json:"outputs"`
+}
+
+// inputStatus is an element of the response of the /inputs endpoint.
+type inputStatus struct {
+	Name            string     `json:"name"`
+	LastGather      *time.Time `json:"last_gather,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+	MetricsGathered int64      `json:"metrics_gathered"`
+}
+
+// outputStatus is an element of the response of the /outputs endpoint.
+type outputStatus struct {
+	Name            string     `json:"name"`
+	BufferSize      int        `json:"buffer_size"`
+	BufferLimit     int        `json:"buffer_limit"`
+	LastWrite       *time.Time `json:"last_write,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+	MetricsWritten  int64      `json:"metrics_written"`
+	MetricsFiltered int64      `json:"metrics_filtered"`
+	MetricsRejected int64      `json:"metrics_rejected"`
+}
+
+// checkAdminAddress verifies that the admin API is only reachable locally.
+func checkAdminAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("Invalid admin_address %q: %s", address, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("Invalid admin_address %q: must be a loopback address",
+			address)
+	}
+	return nil
+}
+
+// startAdmin starts serving the admin API on the given address.
+func (a *Agent) startAdmin(address string) (*http.Server, error) {
+	if err := checkAdminAddress(address); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &http.Server{Handler: a.adminHandler(ln.Addr().String())}
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("E! Admin API stopped: %s\n", err)
+		}
+	}()
+	log.Printf("I! Admin API listening on %s\n", ln.Addr())
+	return srv, nil
+}
+
+// adminHandler returns the handler of the admin API listening on the given
+// address. Requests for another host, or from a page of another origin, are
+// rejected so that browsers can't reach the API through DNS rebinding or
+// cross-site requests.
+func (a *Agent) adminHandler(address string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/plugins", a.handlePlugins)
+	mux.HandleFunc("/inputs", a.handleInputs)
+	mux.HandleFunc("/outputs", a.handleOutputs)
+	mux.HandleFunc("/flush", a.handleFlush)
+	mux.HandleFunc("/gather", a.handleGather)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAdminHost(r.Host, address) {
+			http.Error(w, "forbidden host", http.StatusForbidden)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || !isAdminHost(u.Host, address) {
+				http.Error(w, "forbidden origin", http.StatusForbidden)
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// isAdminHost returns true if host is the address the admin API listens on,
+// or localhost with the same port.
+func isAdminHost(host string, address string) bool {
+	if host == address {
+		return true
+	}
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	return host == net.JoinHostPort("localhost", port)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("E! Error writing admin API response: %s\n", err)
+	}
+}
+
+// handlePlugins lists the loaded plugins with their effective configuration.
+func (a *Agent) handlePlugins(w http.ResponseWriter, r *http.Request) {
+	a.mu.RLock()
+	list := pluginList{
+		Inputs:      make([]pluginInfo, 0, len(a.Config.Inputs)),
+		Processors:  make([]pluginInfo, 0, len(a.Config.Processors)),
+		Aggregators: make([]pluginInfo, 0, len(a.Config.Aggregators)),
+		Outputs:     make([]pluginInfo, 0, len(a.Config.Outputs)),
+	}
+	for _, p := range a.Config.Inputs {
+		list.Inputs = append(list.Inputs,
+			newPluginInfo(a.Config, p.Config.Name, p.Config, p.Input))
+	}
+	for _, p := range a.Config.Processors {
+		list.Processors = append(list.Processors,
+			newPluginInfo(a.Config, p.Name, p.Config, p.Processor))
+	}
+	for _, p := range a.Config.Aggregators {
+		list.Aggregators = append(list.Aggregators,
+			newPluginInfo(a.Config, p.Config.Name, p.Config, p.Aggregator()))
+	}
+	for _, p := range a.Config.Outputs {
+		list.Outputs = append(list.Outputs,
+			newPluginInfo(a.Config, p.Name, p.Config, p.Output))
+	}
+	a.mu.RUnlock()
+
+	writeJSON(w, list)
+}
+
+// handleInputs shows the outcome of the gathers of each input.
+func (a *Agent) handleInputs(w http.ResponseWriter, r *http.Request) {
+	a.mu.RLock()
+	inputs := a.Config.Inputs
+	a.mu.RUnlock()
+
+	status := make([]inputStatus, 0, len(inputs))
+	for _, input := range inputs {
+		last, err := input.LastGather()
+		s := inputStatus{
+			Name:            input.Config.Name,
+			MetricsGathered: input.MetricsGathered.Get(),
+		}
+		if !last.IsZero() {
+			s.LastGather = &last
+		}
+		if err != nil {
+			s.LastError = err.Error()
+		}
+		status = append(status, s)
+	}
+	writeJSON(w, status)
+}
+
+// handleOutputs shows the buffer fill and the outcome of the writes of each
+// output.
+func (a *Agent) handleOutputs(w http.ResponseWriter, r *http.Request) {
+	outputs := a.outputs()
+
+	status := make([]outputStatus, 0, len(outputs))
+	for _, o := range outputs {
+		last, err := o.LastWrite()
+		s := outputStatus{
+			Name:            o.Name,
+			BufferSize:      o.BufferLen(),
+			BufferLimit:     o.MetricBufferLimit,
+			MetricsWritten:  o.MetricsWritten.Get(),
+			MetricsFiltered: o.MetricsFiltered.Get(),
+			MetricsRejected: o.MetricsRejected.Get(),
+		}
+		if !last.IsZero() {
+			s.LastWrite = &last
+		}
+		if err != nil {
+			s.LastError = err.Error()
+		}
+		status = append(status, s)
+	}
+	writeJSON(w, status)
+}
+
+// handleFlush writes the buffered metrics of all outputs.
+func (a *Agent) handleFlush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	a.flush()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGather gathers once from the inputs named by the input parameter.
+// The gathers time out like scheduled ones, or when the request is canceled.
+func (a *Agent) handleGather(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := r.URL.Query().Get("input")
+
+	a.mu.RLock()
+	metricC := a.metricC
+	inputs := a.Config.Inputs
+	a.mu.RUnlock()
+	if metricC == nil {
+		http.Error(w, "agent is not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	var found, timedOut bool
+	var errs []string
+	for _, input := range inputs {
+		if input.Config.Name != name {
+			continue
+		}
+		found = true
+
+		acc := NewAccumulator(input, metricC)
+		acc.SetPrecision(a.Config.Agent.Precision.Duration,
+			a.Config.Agent.Interval.Duration)
+		timeout := input.Config.GatherTimeout
+		if timeout == 0 {
+			timeout = a.Config.Agent.Interval.Duration
+		}
+		if err := gatherContext(r.Context(), input, acc, timeout); err != nil {
+			acc.AddError(err)
+			errs = append(errs, err.Error())
+			if err == context.DeadlineExceeded || err == context.Canceled {
+				timedOut = true
+			}
+		}
+	}
+
+	switch {
+	case !found:
+		http.Error(w, fmt.Sprintf("input %q not found", name), http.StatusNotFound)
+	case timedOut:
+		http.Error(w, strings.Join(errs, "\n"), http.StatusGatewayTimeout)
+	case len(errs) > 0:
+		http.Error(w, strings.Join(errs, "\n"), http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// gatherContext gathers from the input until ctx is done or the timeout is
+// reached. A gather still running then is left to finish in the background,
+// as gatherWithTimeout does, while a gather still waiting for a previous one
+// to return is not run at all.
+func gatherContext(
+	ctx context.Context,
+	input *models.RunningInput,
+	acc telegraf.Accumulator,
+	timeout time.Duration,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- input.GatherContext(ctx, acc)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func newPluginInfo(
+	c *config.Config,
+	name string,
+	settings interface{},
+	plugin interface{},
+) pluginInfo {
+	return pluginInfo{
+		Name:     name,
+		Settings: configValue(c, reflect.ValueOf(settings), 0),
+		Config:   configValue(c, reflect.ValueOf(plugin), 0),
+	}
+}
+
+// configValue converts the exported fields of a plugin or its settings to a
+// value that can be encoded to JSON, with the secrets redacted. Values that
+// can't be represented, such as functions and channels, are omitted.
+func configValue(c *config.Config, v reflect.Value, depth int) interface{} {
+	if !v.IsValid() || depth > 8 {
+		return nil
+	}
+
+	switch v.Type() {
+	case durationType:
+		return time.Duration(v.Int()).String()
+	case internalDurationType:
+		return v.Interface().(internal.Duration).Duration.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		// Structs referenced by a plugin, such as clients, usually hold its
+		// runtime state rather than settings.
+		if depth > 0 && reflect.Indirect(v.Elem()).Kind() == reflect.Struct {
+			return nil
+		}
+		return configValue(c, v.Elem(), depth+1)
+	case reflect.Struct:
+		out := make(map[string]interface{})
+		addStructFields(c, out, v, depth)
+		return out
+	case reflect.Slice, reflect.Array:
+		out := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if elem := configValue(c, v.Index(i), depth+1); elem != nil {
+				out = append(out, elem)
+			}
+		}
+		return out
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		out := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			out[key.String()] = redact(c, key.String(), v.MapIndex(key), depth)
+		}
+		return out
+	case reflect.String:
+		return redactURL(c.RedactSecrets(v.String()))
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return v.Interface()
+	}
+	return nil
+}
+
+// addStructFields adds the exported fields of a struct to out, named like
+// their configuration setting. The fields of embedded structs are added as if
+// they were fields of the struct itself.
+func addStructFields(
+	c *config.Config,
+	out map[string]interface{},
+	v reflect.Value,
+	depth int,
+) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			addStructFields(c, out, v.Field(i), depth+1)
+			continue
+		}
+
+		name := field.Tag.Get("toml")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = internal.SnakeCase(field.Name)
+		}
+		if value := redact(c, name, v.Field(i), depth); value != nil {
+			out[name] = value
+		}
+	}
+}
+
+// redact returns the value of the setting with the given name, or a
+// placeholder if the setting holds a secret.
+func redact(c *config.Config, name string, v reflect.Value, depth int) interface{} {
+	value := configValue(c, v, depth+1)
+	if !secretRe.MatchString(name) {
+		return value
+	}
+	switch s := value.(type) {
+	case string:
+		if s != "" {
+			return redacted
+		}
+	case []interface{}:
+		if len(s) > 0 {
+			return redacted
+		}
+	}
+	return value
+}
+
+// redactURL redacts the password of a URL with user information.
+func redactURL(s string) string {
+	if !strings.Contains(s, "@") {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	if _, ok := u.User.Password(); !ok {
+		return s
+	}
+	u.User = url.UserPassword(u.User.Username(), "redacted")
+	return u.String()
+}
@@ -1,9 +1,12 @@
 package agent
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"reflect"
 	"runtime"
 	"sync"
 	"time"
@@ -15,9 +18,50 @@ import (
 	"github.com/influxdata/telegraf/selfstat"
 )
 
+// ErrFullReload is returned by Reload when the agent settings or the global
+// tags changed, the agent must then be restarted with the new config.
+var ErrFullReload = errors.New("agent settings changed, a full reload is required")
+
 // Agent runs telegraf and collects data based on the given config
 type Agent struct {
 	Config *config.Config
+
+	// mu guards the plugins of Config, which are replaced by Reload while
+	// the agent is running.
+	mu sync.RWMutex
+
+	// Set by Run for starting and stopping plugins while running.
+	metricC     chan telegraf.Metric
+	aggC        chan telegraf.Metric
+	wg          sync.WaitGroup
+	inputs      map[*models.RunningInput]*pluginRunner
+	aggregators map[*models.RunningAggregator]*pluginRunner
+	writers     map[*models.RunningOutput]*pluginRunner
+	stopped     bool
+
+	// processorsVersion is incremented when Reload replaces the processors,
+	// for the pipelines to be rebuilt.
+	processorsVersion int
+}
+
+// pluginRunner controls the goroutine running an input, an aggregator or the
+// flushes of an output.
+type pluginRunner struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newPluginRunner() *pluginRunner {
+	return &pluginRunner{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Stop stops the plugin and waits for its goroutine to return.
+func (r *pluginRunner) Stop() {
+	close(r.stop)
+	<-r.done
 }
 
 // NewAgent returns an Agent struct based off the given Config
@@ -26,46 +70,77 @@ func NewAgent(config *config.Config) (*Agent, error) {
 		Config: config,
 	}
 
-	if !a.Config.Agent.OmitHostname {
-		if a.Config.Agent.Hostname == "" {
-			hostname, err := os.Hostname()
-			if err != nil {
-				return nil, err
-			}
+	if err := setHostname(config); err != nil {
+		return nil, err
+	}
 
-			a.Config.Agent.Hostname = hostname
+	if config.Agent.AdminAddress != "" {
+		if err := checkAdminAddress(config.Agent.AdminAddress); err != nil {
+			return nil, err
 		}
-
-		config.Tags["host"] = a.Config.Agent.Hostname
 	}
 
 	return a, nil
 }
 
+// setHostname sets the hostname of the agent and the host tag of the config.
+func setHostname(c *config.Config) error {
+	if c.Agent.OmitHostname {
+		return nil
+	}
+
+	if c.Agent.Hostname == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+
+		c.Agent.Hostname = hostname
+	}
+
+	c.Tags["host"] = c.Agent.Hostname
+	return nil
+}
+
 // Connect connects to all configured outputs
 func (a *Agent) Connect() error {
 	for _, o := range a.Config.Outputs {
-		switch ot := o.Output.(type) {
-		case telegraf.ServiceOutput:
-			if err := ot.Start(); err != nil {
-				log.Printf("E! Service for output %s failed to start, exiting\n%s\n",
-					o.Name, err.Error())
-				return err
-			}
+		if err := connectOutput(o); err != nil {
+			return err
 		}
+	}
+	return nil
+}
+
+// connectOutput opens the buffer of a single output, then starts and
+// connects it along with its dead letter output
+func connectOutput(o *models.RunningOutput) error {
+	o.OpenBuffer()
+
+	switch ot := o.Output.(type) {
+	case telegraf.ServiceOutput:
+		if err := ot.Start(); err != nil {
+			log.Printf("E! Service for output %s failed to start, exiting\n%s\n",
+				o.Name, err.Error())
+			return err
+		}
+	}
 
-		log.Printf("D! Attempting connection to output: %s\n", o.Name)
-		err := o.Output.Connect()
+	log.Printf("D! Attempting connection to output: %s\n", o.Name)
+	err := o.Output.Connect()
+	if err != nil {
+		log.Printf("E! Failed to connect to output %s, retrying in 15s, "+
+			"error was '%s' \n", o.Name, err)
+		time.Sleep(15 * time.Second)
+		err = o.Output.Connect()
 		if err != nil {
-			log.Printf("E! Failed to connect to output %s, retrying in 15s, "+
-				"error was '%s' \n", o.Name, err)
-			time.Sleep(15 * time.Second)
-			err = o.Output.Connect()
-			if err != nil {
-				return err
-			}
+			return err
 		}
-		log.Printf("D! Successfully connected to output: %s\n", o.Name)
+	}
+	log.Printf("D! Successfully connected to output: %s\n", o.Name)
+
+	if dl := o.DeadLetter(); dl != nil {
+		return connectOutput(dl)
 	}
 	return nil
 }
@@ -73,9 +148,21 @@ func (a *Agent) Connect() error {
 // Close closes the connection to all configured outputs
 func (a *Agent) Close() error {
 	var err error
-	for _, o := range a.Config.Outputs {
-		err = o.Output.Close()
-		switch ot := o.Output.(type) {
+	for _, o := range a.outputs() {
+		err = closeOutput(o)
+	}
+	return err
+}
+
+// closeOutput closes and stops a single output
+func closeOutput(o *models.RunningOutput) error {
+	err := o.Close()
+	switch ot := o.Output.(type) {
+	case telegraf.ServiceOutput:
+		ot.Stop()
+	}
+	if dl := o.DeadLetter(); dl != nil {
+		switch ot := dl.Output.(type) {
 		case telegraf.ServiceOutput:
 			ot.Stop()
 		}
@@ -83,6 +170,13 @@ func (a *Agent) Close() error {
 	return err
 }
 
+// outputs returns the outputs currently in use.
+func (a *Agent) outputs() []*models.RunningOutput {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.Config.Outputs
+}
+
 func panicRecover(input *models.RunningInput) {
 	if err := recover(); err != nil {
 		trace := make([]byte, 2048)
@@ -100,7 +194,8 @@ func panicRecover(input *models.RunningInput) {
 func (a *Agent) gatherer(
 	shutdown chan struct{},
 	input *models.RunningInput,
-	interval time.Duration,
+	sched schedule,
+	jitter time.Duration,
 	metricC chan telegraf.Metric,
 ) {
 	defer panicRecover(input)
@@ -114,59 +209,95 @@ func (a *Agent) gatherer(
 	acc.SetPrecision(a.Config.Agent.Precision.Duration,
 		a.Config.Agent.Interval.Duration)
 
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
+	next := sched.Next(time.Now())
 	for {
-		internal.RandomSleep(a.Config.Agent.CollectionJitter.Duration, shutdown)
-
-		start := time.Now()
-		gatherWithTimeout(shutdown, input, acc, interval)
-		elapsed := time.Since(start)
-
-		GatherTime.Incr(elapsed.Nanoseconds())
+		if next.IsZero() {
+			log.Printf("I! Input %s has no further scheduled gathers\n",
+				input.Name())
+			return
+		}
 
 		select {
 		case <-shutdown:
 			return
-		case <-ticker.C:
-			continue
+		case <-time.After(time.Until(next)):
+		}
+
+		// Unless configured otherwise, the gather times out when the
+		// following one is due.
+		following := sched.Next(next.Add(time.Nanosecond))
+		timeout := input.Config.GatherTimeout
+		if timeout == 0 {
+			timeout = following.Sub(next)
+			if following.IsZero() {
+				timeout = a.Config.Agent.Interval.Duration
+			}
 		}
+
+		internal.RandomSleep(jitter, shutdown)
+
+		start := time.Now()
+		next = gatherWithTimeout(shutdown, input, acc, timeout, sched, following)
+		elapsed := time.Since(start)
+
+		GatherTime.Incr(elapsed.Nanoseconds())
 	}
 }
 
 // gatherWithTimeout gathers from the given input, with the given timeout.
 //   when the given timeout is reached, gatherWithTimeout logs an error message
-//   but continues waiting for it to return. This is to avoid leaving behind
-//   hung processes, and to prevent re-calling the same hung process over and
-//   over.
+//   and cancels the gather, which aborts it if the input is a
+//   telegraf.ContextInput. Otherwise it continues waiting for it to return.
+//   This is to avoid leaving behind hung processes, and to prevent
+//   re-calling the same hung process over and over. The gathers scheduled
+//   from next on while waiting are skipped and counted. It returns the time
+//   of the next gather.
 func gatherWithTimeout(
 	shutdown chan struct{},
 	input *models.RunningInput,
 	acc telegraf.Accumulator,
 	timeout time.Duration,
-) {
-	ticker := time.NewTicker(timeout)
-	defer ticker.Stop()
-	done := make(chan error)
+	sched schedule,
+	next time.Time,
+) time.Time {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	done := make(chan error, 1)
 	go func() {
-		done <- input.Input.Gather(acc)
+		done <- input.GatherContext(ctx, acc)
 	}()
 
+	timeoutC := ctx.Done()
+	skip := time.NewTimer(time.Until(next))
+	defer skip.Stop()
+
 	for {
 		select {
 		case err := <-done:
 			if err != nil {
 				acc.AddError(err)
 			}
-			return
-		case <-ticker.C:
-			err := fmt.Errorf("took longer to collect than collection interval (%s)",
+			for !next.IsZero() && !next.After(time.Now()) {
+				input.GatherSkipped.Incr(1)
+				next = sched.Next(next.Add(time.Nanosecond))
+			}
+			return next
+		case <-timeoutC:
+			timeoutC = nil
+			err := fmt.Errorf("took longer to collect than gather timeout (%s)",
 				timeout)
 			acc.AddError(err)
-			continue
+		case <-skip.C:
+			if next.IsZero() {
+				continue
+			}
+			input.GatherSkipped.Incr(1)
+			next = sched.Next(next.Add(time.Nanosecond))
+			if !next.IsZero() {
+				skip.Reset(time.Until(next))
+			}
 		case <-shutdown:
-			return
+			return next
 		}
 	}
 }
@@ -203,7 +334,7 @@ func (a *Agent) Test() error {
 		input.SetTrace(true)
 		input.SetDefaultTags(a.Config.Tags)
 
-		if err := input.Input.Gather(acc); err != nil {
+		if err := input.Gather(acc); err != nil {
 			return err
 		}
 
@@ -212,7 +343,7 @@ func (a *Agent) Test() error {
 		switch input.Name() {
 		case "inputs.cpu", "inputs.mongodb", "inputs.procstat":
 			time.Sleep(500 * time.Millisecond)
-			if err := input.Input.Gather(acc); err != nil {
+			if err := input.Gather(acc); err != nil {
 				return err
 			}
 		}
@@ -225,8 +356,9 @@ func (a *Agent) Test() error {
 func (a *Agent) flush() {
 	var wg sync.WaitGroup
 
-	wg.Add(len(a.Config.Outputs))
-	for _, o := range a.Config.Outputs {
+	outputs := a.outputs()
+	wg.Add(len(outputs))
+	for _, o := range outputs {
 		go func(output *models.RunningOutput) {
 			defer wg.Done()
 			err := output.Write()
@@ -240,186 +372,426 @@ func (a *Agent) flush() {
 	wg.Wait()
 }
 
-// flusher monitors the metrics input channel and flushes on the minimum interval
+// writer writes the output every interval until shutdown. Each output has its
+// own writer, so a slow output does not delay the others.
+func (a *Agent) writer(
+	shutdown chan struct{},
+	output *models.RunningOutput,
+	interval time.Duration,
+	jitter time.Duration,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-shutdown:
+			return
+		case <-ticker.C:
+			internal.RandomSleep(jitter, shutdown)
+			if err := output.Write(); err != nil {
+				log.Printf("E! Error writing to output [%s]: %s\n",
+					output.Name, err.Error())
+			}
+		}
+	}
+}
+
+// flusher routes the metrics of the inputs and aggregators through the
+// processors to the outputs and flushes all outputs on shutdown
 func (a *Agent) flusher(shutdown chan struct{}, metricC chan telegraf.Metric, aggC chan telegraf.Metric) error {
 	// Inelegant, but this sleep is to allow the Gather threads to run, so that
 	// the flusher will flush after metrics are collected.
 	time.Sleep(time.Millisecond * 300)
 
 	// create an output metric channel and a gorouting that continuously passes
-	// each metric onto the output plugins & aggregators.
+	// each processed metric onto the output plugins & aggregators.
 	outMetricC := make(chan telegraf.Metric, 100)
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		for {
-			select {
-			case <-shutdown:
-				if len(outMetricC) > 0 {
-					// keep going until outMetricC is flushed
-					continue
-				}
-				return
-			case m := <-outMetricC:
-				// if dropOriginal is set to true, then we will only send this
-				// metric to the aggregators, not the outputs.
-				var dropOriginal bool
-				for _, agg := range a.Config.Aggregators {
-					if ok := agg.Add(m.Copy()); ok {
-						dropOriginal = true
-					}
-				}
-				if !dropOriginal {
-					for i, o := range a.Config.Outputs {
-						if i == len(a.Config.Outputs)-1 {
-							o.AddMetric(m)
-						} else {
-							o.AddMetric(m.Copy())
-						}
-					}
+		for m := range outMetricC {
+			a.mu.RLock()
+			// if dropOriginal is set to true, then we will only send this
+			// metric to the aggregators, not the outputs.
+			var dropOriginal bool
+			for _, agg := range a.Config.Aggregators {
+				if ok := agg.Add(m.Copy()); ok {
+					dropOriginal = true
 				}
 			}
+			if !dropOriginal {
+				a.addMetric(m)
+			}
+			a.mu.RUnlock()
 		}
 	}()
 
+	aggOutC := make(chan telegraf.Metric, 100)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		for {
-			select {
-			case <-shutdown:
-				if len(aggC) > 0 {
-					// keep going until aggC is flushed
-					continue
-				}
-				return
-			case metric := <-aggC:
-				metrics := []telegraf.Metric{metric}
-				for _, processor := range a.Config.Processors {
-					metrics = processor.Apply(metrics...)
-				}
-				for _, m := range metrics {
-					for i, o := range a.Config.Outputs {
-						if i == len(a.Config.Outputs)-1 {
-							o.AddMetric(m)
-						} else {
-							o.AddMetric(m.Copy())
-						}
-					}
-				}
-			}
+		a.process(shutdown, aggC, aggOutC)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for m := range aggOutC {
+			a.mu.RLock()
+			a.addMetric(m)
+			a.mu.RUnlock()
 		}
 	}()
 
-	ticker := time.NewTicker(a.Config.Agent.FlushInterval.Duration)
-	semaphore := make(chan struct{}, 1)
-	for {
-		select {
-		case <-shutdown:
-			log.Println("I! Hang on, flushing any cached metrics before shutdown")
-			// wait for outMetricC to get flushed before flushing outputs
-			wg.Wait()
-			a.flush()
-			return nil
-		case <-ticker.C:
-			go func() {
-				select {
-				case semaphore <- struct{}{}:
-					internal.RandomSleep(a.Config.Agent.FlushJitter.Duration, shutdown)
-					a.flush()
-					<-semaphore
-				default:
-					// skipping this flush because one is already happening
-					log.Println("W! Skipping a scheduled flush because there is" +
-						" already a flush ongoing.")
-				}
-			}()
-		case metric := <-metricC:
-			// NOTE potential bottleneck here as we put each metric through the
-			// processors serially.
-			mS := []telegraf.Metric{metric}
-			for _, processor := range a.Config.Processors {
-				mS = processor.Apply(mS...)
-			}
-			for _, m := range mS {
-				outMetricC <- m
-			}
+	a.process(shutdown, metricC, outMetricC)
+	log.Println("I! Hang on, flushing any cached metrics before shutdown")
+	// wait for the processed metrics to get flushed before flushing outputs
+	wg.Wait()
+	var pending sync.WaitGroup
+	for _, o := range a.outputs() {
+		o.StopProcessors()
+		// a write that timed out may still be running, give it a chance to
+		// return so that the output is flushed
+		pending.Add(1)
+		go func(output *models.RunningOutput) {
+			defer pending.Done()
+			output.WaitWrite()
+		}(o)
+	}
+	pending.Wait()
+	a.flush()
+	return nil
+}
+
+// addMetric adds the metric to all outputs. It must be called with mu held.
+func (a *Agent) addMetric(m telegraf.Metric) {
+	for i, o := range a.Config.Outputs {
+		if i == len(a.Config.Outputs)-1 {
+			o.AddMetric(m)
+		} else {
+			o.AddMetric(m.Copy())
 		}
 	}
 }
 
 // Run runs the agent daemon, gathering every Interval
 func (a *Agent) Run(shutdown chan struct{}) error {
-	var wg sync.WaitGroup
-
 	log.Printf("I! Agent Config: Interval:%s, Quiet:%#v, Hostname:%#v, "+
 		"Flush Interval:%s \n",
 		a.Config.Agent.Interval.Duration, a.Config.Agent.Quiet,
 		a.Config.Agent.Hostname, a.Config.Agent.FlushInterval.Duration)
 
 	// channel shared between all input threads for accumulating metrics
-	metricC := make(chan telegraf.Metric, 100)
-	aggC := make(chan telegraf.Metric, 100)
+	a.mu.Lock()
+	a.metricC = make(chan telegraf.Metric, 100)
+	a.aggC = make(chan telegraf.Metric, 100)
+	a.inputs = make(map[*models.RunningInput]*pluginRunner)
+	a.aggregators = make(map[*models.RunningAggregator]*pluginRunner)
+	a.writers = make(map[*models.RunningOutput]*pluginRunner)
+	a.mu.Unlock()
+
+	if address := a.Config.Agent.AdminAddress; address != "" {
+		srv, err := a.startAdmin(address)
+		if err != nil {
+			log.Printf("E! Unable to start the admin API: %s\n", err)
+		} else {
+			defer srv.Close()
+		}
+	}
 
 	// Start all ServicePlugins
+	if err := a.startServices(a.Config.Inputs); err != nil {
+		return err
+	}
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		if err := a.flusher(shutdown, a.metricC, a.aggC); err != nil {
+			log.Printf("E! Flusher routine failed, exiting: %s\n", err.Error())
+			close(shutdown)
+		}
+	}()
+
+	a.mu.Lock()
+	for _, o := range a.Config.Outputs {
+		a.startWriter(o)
+	}
+	for _, aggregator := range a.Config.Aggregators {
+		a.startAggregator(aggregator)
+	}
 	for _, input := range a.Config.Inputs {
+		a.startInput(input)
+	}
+	a.mu.Unlock()
+
+	go func() {
+		<-shutdown
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.stopped = true
+		for _, r := range a.inputs {
+			close(r.stop)
+		}
+		for _, r := range a.aggregators {
+			close(r.stop)
+		}
+		for _, r := range a.writers {
+			close(r.stop)
+		}
+	}()
+
+	a.wg.Wait()
+	a.Close()
+	a.mu.RLock()
+	stopServices(a.Config.Inputs)
+	a.mu.RUnlock()
+	return nil
+}
+
+// startServices starts the given service inputs. If one of them fails to
+// start, the inputs started so far are stopped again.
+func (a *Agent) startServices(inputs []*models.RunningInput) error {
+	for i, input := range inputs {
 		input.SetDefaultTags(a.Config.Tags)
 		switch p := input.Input.(type) {
 		case telegraf.ServiceInput:
-			acc := NewAccumulator(input, metricC)
+			acc := NewAccumulator(input, a.metricC)
 			// Service input plugins should set their own precision of their
 			// metrics.
 			acc.SetPrecision(time.Nanosecond, 0)
 			if err := p.Start(acc); err != nil {
 				log.Printf("E! Service for input %s failed to start, exiting\n%s\n",
 					input.Name(), err.Error())
+				stopServices(inputs[:i])
 				return err
 			}
-			defer p.Stop()
 		}
 	}
+	return nil
+}
+
+// stopServices stops the given service inputs.
+func stopServices(inputs []*models.RunningInput) {
+	for _, input := range inputs {
+		switch p := input.Input.(type) {
+		case telegraf.ServiceInput:
+			p.Stop()
+		}
+	}
+}
 
-	// Round collection to nearest interval by sleeping
-	if a.Config.Agent.RoundInterval {
-		i := int64(a.Config.Agent.Interval.Duration)
-		time.Sleep(time.Duration(i - (time.Now().UnixNano() % i)))
+// startInput starts gathering the input in its own goroutine. It must be
+// called with mu held.
+func (a *Agent) startInput(input *models.RunningInput) {
+	sched := a.inputSchedule(input)
+	jitter := a.Config.Agent.CollectionJitter.Duration
+	if input.Config.CollectionJitter != 0 {
+		jitter = input.Config.CollectionJitter
 	}
 
-	wg.Add(1)
+	r := newPluginRunner()
+	a.inputs[input] = r
+	a.wg.Add(1)
 	go func() {
-		defer wg.Done()
-		if err := a.flusher(shutdown, metricC, aggC); err != nil {
-			log.Printf("E! Flusher routine failed, exiting: %s\n", err.Error())
-			close(shutdown)
-		}
+		defer a.wg.Done()
+		defer close(r.done)
+		a.gatherer(r.stop, input, sched, jitter, a.metricC)
 	}()
+}
 
-	wg.Add(len(a.Config.Aggregators))
-	for _, aggregator := range a.Config.Aggregators {
-		go func(agg *models.RunningAggregator) {
-			defer wg.Done()
-			acc := NewAccumulator(agg, aggC)
-			acc.SetPrecision(a.Config.Agent.Precision.Duration,
-				a.Config.Agent.Interval.Duration)
-			agg.Run(acc, shutdown)
-		}(aggregator)
+// startAggregator runs the aggregator in its own goroutine. It must be called
+// with mu held.
+func (a *Agent) startAggregator(agg *models.RunningAggregator) {
+	r := newPluginRunner()
+	a.aggregators[agg] = r
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		defer close(r.done)
+		acc := NewAccumulator(agg, a.aggC)
+		acc.SetPrecision(a.Config.Agent.Precision.Duration,
+			a.Config.Agent.Interval.Duration)
+		agg.Run(acc, r.stop)
+	}()
+}
+
+// startWriter starts the processors of the output and writes it on its flush
+// interval in its own goroutine. It must be called with mu held.
+func (a *Agent) startWriter(o *models.RunningOutput) {
+	o.StartProcessors()
+
+	interval := a.Config.Agent.FlushInterval.Duration
+	if o.Config.FlushInterval != 0 {
+		interval = o.Config.FlushInterval
+	}
+	jitter := a.Config.Agent.FlushJitter.Duration
+	if o.Config.FlushJitter != 0 {
+		jitter = o.Config.FlushJitter
 	}
 
-	wg.Add(len(a.Config.Inputs))
-	for _, input := range a.Config.Inputs {
-		interval := a.Config.Agent.Interval.Duration
-		// overwrite global interval if this plugin has it's own.
-		if input.Config.Interval != 0 {
-			interval = input.Config.Interval
+	r := newPluginRunner()
+	a.writers[o] = r
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		defer close(r.done)
+		a.writer(r.stop, o, interval, jitter)
+	}()
+}
+
+// Reload replaces the plugins of the running agent with the plugins of the
+// given config. Only the plugins that are not part of both configs are
+// started or stopped, the others keep running along with their state. To
+// keep plugins whose configuration did not change, c must have been loaded
+// with config.ReusePlugins.
+//
+// If the agent settings or the global tags changed, Reload returns
+// ErrFullReload without changing anything.
+func (a *Agent) Reload(c *config.Config) error {
+	if err := setHostname(c); err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(a.Config.Agent, c.Agent) ||
+		!reflect.DeepEqual(a.Config.Tags, c.Tags) {
+		return ErrFullReload
+	}
+
+	a.mu.RLock()
+	running := a.inputs != nil && !a.stopped
+	prev := map[interface{}]bool{}
+	for _, p := range a.Config.Inputs {
+		prev[p] = true
+	}
+	for _, p := range a.Config.Outputs {
+		prev[p] = true
+	}
+	for _, p := range a.Config.Aggregators {
+		prev[p] = true
+	}
+	a.mu.RUnlock()
+	if !running {
+		return errors.New("agent is not running")
+	}
+
+	var newInputs []*models.RunningInput
+	for _, input := range c.Inputs {
+		if !prev[input] {
+			newInputs = append(newInputs, input)
+		}
+	}
+	var newOutputs, keptOutputs []*models.RunningOutput
+	for _, o := range c.Outputs {
+		if !prev[o] {
+			newOutputs = append(newOutputs, o)
+		} else {
+			keptOutputs = append(keptOutputs, o)
 		}
-		go func(in *models.RunningInput, interv time.Duration) {
-			defer wg.Done()
-			a.gatherer(shutdown, in, interv, metricC)
-		}(input, interval)
 	}
 
-	wg.Wait()
-	a.Close()
+	if err := a.startServices(newInputs); err != nil {
+		return err
+	}
+
+	// Replace the plugins, the new outputs are only added once connected.
+	a.mu.Lock()
+	if a.stopped {
+		a.mu.Unlock()
+		stopServices(newInputs)
+		return errors.New("agent is not running")
+	}
+	next := map[interface{}]bool{}
+	for _, p := range c.Inputs {
+		next[p] = true
+	}
+	for _, p := range c.Outputs {
+		next[p] = true
+	}
+	for _, p := range c.Aggregators {
+		next[p] = true
+	}
+	var stopped []*pluginRunner
+	var oldInputs []*models.RunningInput
+	for input, r := range a.inputs {
+		if !next[input] {
+			stopped = append(stopped, r)
+			oldInputs = append(oldInputs, input)
+			delete(a.inputs, input)
+		}
+	}
+	var nOldAggregators, nNewAggregators int
+	for agg, r := range a.aggregators {
+		if !next[agg] {
+			stopped = append(stopped, r)
+			nOldAggregators++
+			delete(a.aggregators, agg)
+		}
+	}
+	var oldOutputs []*models.RunningOutput
+	for _, o := range a.Config.Outputs {
+		if !next[o] {
+			oldOutputs = append(oldOutputs, o)
+			if r, ok := a.writers[o]; ok {
+				stopped = append(stopped, r)
+				delete(a.writers, o)
+			}
+		}
+	}
+	a.Config.Inputs = c.Inputs
+	a.Config.Outputs = keptOutputs
+	a.Config.Aggregators = c.Aggregators
+	a.Config.Processors = c.Processors
+	a.processorsVersion++
+	for _, agg := range c.Aggregators {
+		if _, ok := a.aggregators[agg]; !ok {
+			a.startAggregator(agg)
+			nNewAggregators++
+		}
+	}
+	for _, input := range newInputs {
+		a.startInput(input)
+	}
+	a.mu.Unlock()
+
+	for _, r := range stopped {
+		r.Stop()
+	}
+	stopServices(oldInputs)
+
+	// Flush the removed outputs before connecting the new ones, which may
+	// use the same buffer_path.
+	for _, o := range oldOutputs {
+		o.StopProcessors()
+		if err := o.Write(); err != nil {
+			log.Printf("E! Error writing to output [%s]: %s\n", o.Name, err.Error())
+		}
+		if err := closeOutput(o); err != nil {
+			log.Printf("E! Error closing output [%s]: %s\n", o.Name, err.Error())
+		}
+	}
+	var connected []*models.RunningOutput
+	for _, o := range newOutputs {
+		if err := connectOutput(o); err != nil {
+			log.Printf("E! Output [%s] failed to connect, it will not be "+
+				"used until the next reload: %s\n", o.Name, err)
+			continue
+		}
+		connected = append(connected, o)
+	}
+
+	a.mu.Lock()
+	a.Config.Outputs = append(a.Config.Outputs, connected...)
+	if !a.stopped {
+		for _, o := range connected {
+			a.startWriter(o)
+		}
+	}
+	a.mu.Unlock()
+
+	log.Printf("I! Reloaded config, stopped %d inputs, %d aggregators and %d "+
+		"outputs, started %d inputs, %d aggregators and %d outputs\n",
+		len(oldInputs), nOldAggregators, len(oldOutputs),
+		len(newInputs), nNewAggregators, len(connected))
 	return nil
 }
@@ -1,9 +1,14 @@
 package agent
 
 import (
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
 
 	// needing to load the plugins
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
@@ -11,6 +16,7 @@ import (
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAgent_OmitHostname(t *testing.T) {
@@ -109,3 +115,163 @@ func TestAgent_LoadOutput(t *testing.T) {
 	a, _ = NewAgent(c)
 	assert.Equal(t, 3, len(a.Config.Outputs))
 }
+
+type countingInput struct {
+	sync.Mutex
+	gathers int
+}
+
+func (i *countingInput) Description() string  { return "" }
+func (i *countingInput) SampleConfig() string { return "" }
+func (i *countingInput) Gather(acc telegraf.Accumulator) error {
+	i.Lock()
+	defer i.Unlock()
+	i.gathers++
+	acc.AddFields("counting", map[string]interface{}{"value": 1}, nil)
+	return nil
+}
+
+func (i *countingInput) Gathers() int {
+	i.Lock()
+	defer i.Unlock()
+	return i.gathers
+}
+
+type nopOutput struct {
+	connected bool
+	closed    bool
+}
+
+func (o *nopOutput) Connect() error                        { o.connected = true; return nil }
+func (o *nopOutput) Close() error                          { o.closed = true; return nil }
+func (o *nopOutput) Description() string                   { return "" }
+func (o *nopOutput) SampleConfig() string                  { return "" }
+func (o *nopOutput) Write(metrics []telegraf.Metric) error { return nil }
+
+func newReloadConfig() *config.Config {
+	c := config.NewConfig()
+	c.Agent.OmitHostname = true
+	c.Agent.RoundInterval = false
+	c.Agent.Interval = internal.Duration{Duration: 10 * time.Millisecond}
+	c.Agent.FlushInterval = internal.Duration{Duration: 10 * time.Millisecond}
+	return c
+}
+
+func newInput(id string) *models.RunningInput {
+	ri := models.NewRunningInput(&countingInput{}, &models.InputConfig{Name: "counting"})
+	ri.ID = id
+	return ri
+}
+
+func newOutput(id string) *models.RunningOutput {
+	ro := models.NewRunningOutput("nop", &nopOutput{}, &models.OutputConfig{}, 0, 0)
+	ro.ID = id
+	return ro
+}
+
+func TestAgent_Reload(t *testing.T) {
+	kept, removed := newInput("kept"), newInput("removed")
+	keptOutput, removedOutput := newOutput("kept"), newOutput("removed")
+	c := newReloadConfig()
+	c.Inputs = []*models.RunningInput{kept, removed}
+	c.Outputs = []*models.RunningOutput{keptOutput, removedOutput}
+
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	require.NoError(t, a.Connect())
+
+	shutdown := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		a.Run(shutdown)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	added, addedOutput := newInput("added"), newOutput("added")
+	next := newReloadConfig()
+	next.Inputs = []*models.RunningInput{kept, added}
+	next.Outputs = []*models.RunningOutput{keptOutput, addedOutput}
+	require.NoError(t, a.Reload(next))
+
+	assert.True(t, removedOutput.Output.(*nopOutput).closed)
+	assert.True(t, addedOutput.Output.(*nopOutput).connected)
+	assert.False(t, keptOutput.Output.(*nopOutput).closed)
+	assert.ElementsMatch(t, []*models.RunningOutput{keptOutput, addedOutput},
+		a.Config.Outputs)
+
+	// Let a gather that was running when the input stopped complete.
+	time.Sleep(10 * time.Millisecond)
+	gathers := removed.Input.(*countingInput).Gathers()
+	keptGathers := kept.Input.(*countingInput).Gathers()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, gathers, removed.Input.(*countingInput).Gathers())
+	assert.True(t, kept.Input.(*countingInput).Gathers() > keptGathers)
+	assert.True(t, added.Input.(*countingInput).Gathers() > 0)
+
+	changed := newReloadConfig()
+	changed.Agent.Interval = internal.Duration{Duration: time.Second}
+	changed.Inputs = next.Inputs
+	changed.Outputs = next.Outputs
+	assert.Equal(t, ErrFullReload, a.Reload(changed))
+
+	close(shutdown)
+	<-done
+	assert.True(t, keptOutput.Output.(*nopOutput).closed)
+	assert.True(t, addedOutput.Output.(*nopOutput).closed)
+}
+
+// slowOutput blocks in Write until release is closed.
+type slowOutput struct {
+	nopOutput
+	release chan struct{}
+}
+
+func (o *slowOutput) Write(metrics []telegraf.Metric) error {
+	<-o.release
+	return nil
+}
+
+func TestAgent_FlushPerOutput(t *testing.T) {
+	c := newReloadConfig()
+	c.Agent.FlushInterval = internal.Duration{Duration: time.Hour}
+	c.Inputs = []*models.RunningInput{newInput("input")}
+
+	slow := &slowOutput{release: make(chan struct{})}
+	slowOutput := models.NewRunningOutput("slow", slow, &models.OutputConfig{
+		FlushInterval: 10 * time.Millisecond,
+		WriteTimeout:  20 * time.Millisecond,
+	}, 0, 0)
+	fastOutput := models.NewRunningOutput("fast", &nopOutput{}, &models.OutputConfig{
+		FlushInterval: 10 * time.Millisecond,
+	}, 0, 0)
+	idleOutput := models.NewRunningOutput("idle", &nopOutput{}, &models.OutputConfig{}, 0, 0)
+	c.Outputs = []*models.RunningOutput{slowOutput, fastOutput, idleOutput}
+	for _, o := range c.Outputs {
+		o.MetricsWritten.Set(0)
+	}
+
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	require.NoError(t, a.Connect())
+
+	shutdown := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		a.Run(shutdown)
+		close(done)
+	}()
+	time.Sleep(500 * time.Millisecond)
+
+	assert.True(t, fastOutput.MetricsWritten.Get() > 0)
+	assert.Equal(t, int64(0), slowOutput.MetricsWritten.Get())
+	_, err = slowOutput.LastWrite()
+	assert.Error(t, err)
+	assert.Equal(t, int64(0), idleOutput.MetricsWritten.Get())
+
+	close(slow.release)
+	close(shutdown)
+	<-done
+	assert.True(t, slowOutput.MetricsWritten.Get() > 0)
+	assert.True(t, idleOutput.MetricsWritten.Get() > 0)
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/models"
+)
+
+// pipeline runs metrics through processors. Each processor is a stage
+// running in its own goroutines, connected to the next one by a channel, so
+// that a slow processor does not hold the others.
+type pipeline struct {
+	in   chan telegraf.Metric
+	done chan struct{}
+}
+
+// newPipeline starts a pipeline of the processors sending its results to out.
+func newPipeline(
+	processors []*models.RunningProcessor,
+	out chan<- telegraf.Metric,
+) *pipeline {
+	p := &pipeline{
+		in:   make(chan telegraf.Metric, 100),
+		done: make(chan struct{}),
+	}
+
+	var src <-chan telegraf.Metric = p.in
+	for _, rp := range processors {
+		dst := make(chan telegraf.Metric, 100)
+		go rp.Run(src, dst)
+		src = dst
+	}
+
+	go func() {
+		defer close(p.done)
+		for m := range src {
+			out <- m
+		}
+	}()
+	return p
+}
+
+// close stops the pipeline once all its metrics are sent.
+func (p *pipeline) close() {
+	close(p.in)
+	<-p.done
+}
+
+// process runs the metrics received from src through the processors and
+// sends the results to dst, until shutdown is closed and src drained. It then
+// closes dst once the processors are drained. The pipeline of the processors
+// is rebuilt when Reload replaces them.
+func (a *Agent) process(
+	shutdown chan struct{},
+	src <-chan telegraf.Metric,
+	dst chan<- telegraf.Metric,
+) {
+	a.mu.RLock()
+	version := a.processorsVersion
+	p := newPipeline(a.Config.Processors, dst)
+	a.mu.RUnlock()
+	defer func() {
+		p.close()
+		close(dst)
+	}()
+
+	for {
+		select {
+		case <-shutdown:
+			if len(src) > 0 {
+				// keep going until src is flushed
+				continue
+			}
+			return
+		case m := <-src:
+			a.mu.RLock()
+			changed := version != a.processorsVersion
+			version = a.processorsVersion
+			processors := a.Config.Processors
+			a.mu.RUnlock()
+			if changed {
+				// The metrics in the pipeline are processed before those
+				// of the new one, as both may share processors.
+				p.close()
+				p = newPipeline(processors, dst)
+			}
+			p.in <- m
+		}
+	}
+}
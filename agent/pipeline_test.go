@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renameProcessor renames the metrics.
+type renameProcessor struct {
+	name string
+}
+
+func (r *renameProcessor) SampleConfig() string { return "" }
+func (r *renameProcessor) Description() string  { return "" }
+
+func (r *renameProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		m.SetName(m.Name() + r.name)
+	}
+	return in
+}
+
+func newRenameProcessor(name string, order int64) *models.RunningProcessor {
+	return models.NewRunningProcessor(name,
+		&models.ProcessorConfig{Name: name, Order: order, Workers: 1},
+		&renameProcessor{name: name},
+	)
+}
+
+func TestPipeline(t *testing.T) {
+	out := make(chan telegraf.Metric, 10)
+	p := newPipeline([]*models.RunningProcessor{
+		newRenameProcessor("_a", 1),
+		newRenameProcessor("_b", 2),
+	}, out)
+
+	for i := 0; i < 3; i++ {
+		p.in <- testutil.TestMetric(i, "cpu")
+	}
+	p.close()
+
+	require.Len(t, out, 3)
+	for i := 0; i < 3; i++ {
+		m := <-out
+		assert.Equal(t, "cpu_a_b", m.Name())
+		assert.Equal(t, int64(i), m.Fields()["value"])
+	}
+}
+
+func TestAgent_ProcessReload(t *testing.T) {
+	a := &Agent{Config: config.NewConfig()}
+	a.Config.Processors = models.RunningProcessors{newRenameProcessor("_a", 1)}
+
+	shutdown := make(chan struct{})
+	src := make(chan telegraf.Metric, 10)
+	dst := make(chan telegraf.Metric, 10)
+	done := make(chan struct{})
+	go func() {
+		a.process(shutdown, src, dst)
+		close(done)
+	}()
+
+	src <- testutil.TestMetric(1, "cpu")
+	assert.Equal(t, "cpu_a", (<-dst).Name())
+
+	a.mu.Lock()
+	a.Config.Processors = models.RunningProcessors{newRenameProcessor("_b", 1)}
+	a.processorsVersion++
+	a.mu.Unlock()
+
+	src <- testutil.TestMetric(2, "cpu")
+	assert.Equal(t, "cpu_b", (<-dst).Name())
+
+	src <- testutil.TestMetric(3, "cpu")
+	close(shutdown)
+	<-done
+
+	var names []string
+	for m := range dst {
+		names = append(names, m.Name())
+	}
+	assert.Equal(t, []string{"cpu_b"}, names)
+}
@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"log"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/cron"
+	"github.com/influxdata/telegraf/internal/models"
+)
+
+// schedule determines the times at which an input is gathered.
+type schedule interface {
+	// Next returns the first gather time at or after t.
+	Next(t time.Time) time.Time
+}
+
+// intervalSchedule gathers every interval, shifted by offset. If round is
+// set, the gathers are aligned to multiples of the interval since the Unix
+// epoch, otherwise to the time the schedule started.
+type intervalSchedule struct {
+	interval time.Duration
+	offset   time.Duration
+	round    bool
+	start    time.Time
+}
+
+func (s *intervalSchedule) Next(t time.Time) time.Time {
+	i := int64(s.interval)
+	if s.round {
+		ns := t.Add(-s.offset).UnixNano()
+		rem := ns % i
+		if rem < 0 {
+			rem += i
+		}
+		if rem == 0 {
+			return t
+		}
+		return time.Unix(0, ns-rem+i).Add(s.offset)
+	}
+
+	first := s.start.Add(s.offset)
+	if !first.Before(t) {
+		return first
+	}
+	n := (int64(t.Sub(first)) + i - 1) / i
+	return first.Add(time.Duration(n * i))
+}
+
+// cronSchedule gathers at the times matched by a cron expression.
+type cronSchedule struct {
+	schedule *cron.Schedule
+}
+
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	return s.schedule.Next(t.Add(-time.Nanosecond))
+}
+
+// inputSchedule returns the schedule of the input, falling back to the agent
+// settings for those the input does not set.
+func (a *Agent) inputSchedule(input *models.RunningInput) schedule {
+	if input.Config.Schedule != "" {
+		s, err := cron.Parse(input.Config.Schedule)
+		if err == nil {
+			return &cronSchedule{schedule: s}
+		}
+		log.Printf("E! Invalid schedule for input %s, using its interval "+
+			"instead: %s\n", input.Name(), err)
+	}
+
+	interval := a.Config.Agent.Interval.Duration
+	// overwrite global interval if this plugin has it's own.
+	if input.Config.Interval != 0 {
+		interval = input.Config.Interval
+	}
+	round := a.Config.Agent.RoundInterval
+	if input.Config.RoundInterval != nil {
+		round = *input.Config.RoundInterval
+	}
+	return &intervalSchedule{
+		interval: interval,
+		offset:   input.Config.CollectionOffset,
+		round:    round,
+		start:    time.Now(),
+	}
+}
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/cron"
+	"github.com/influxdata/telegraf/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntervalSchedule_Round(t *testing.T) {
+	s := &intervalSchedule{
+		interval: 10 * time.Second,
+		round:    true,
+	}
+	assert.Equal(t, time.Unix(20, 0), s.Next(time.Unix(12, 0)))
+	assert.Equal(t, time.Unix(20, 0), s.Next(time.Unix(20, 0)))
+
+	s.offset = 3 * time.Second
+	assert.Equal(t, time.Unix(13, 0), s.Next(time.Unix(12, 0)))
+	assert.Equal(t, time.Unix(23, 0), s.Next(time.Unix(14, 0)))
+}
+
+func TestIntervalSchedule_FreeRunning(t *testing.T) {
+	s := &intervalSchedule{
+		interval: 10 * time.Second,
+		start:    time.Unix(12, 0),
+	}
+	assert.Equal(t, time.Unix(12, 0), s.Next(time.Unix(12, 0)))
+	assert.Equal(t, time.Unix(22, 0), s.Next(time.Unix(12, 1)))
+	assert.Equal(t, time.Unix(42, 0), s.Next(time.Unix(35, 0)))
+
+	s.offset = 5 * time.Second
+	assert.Equal(t, time.Unix(17, 0), s.Next(time.Unix(12, 0)))
+	assert.Equal(t, time.Unix(27, 0), s.Next(time.Unix(18, 0)))
+}
+
+func TestCronSchedule(t *testing.T) {
+	c, err := cron.Parse("5 * * * *")
+	require.NoError(t, err)
+	s := &cronSchedule{schedule: c}
+
+	at := time.Date(2018, 7, 10, 10, 5, 0, 0, time.UTC)
+	assert.Equal(t, at, s.Next(at))
+	assert.Equal(t, at.Add(time.Hour), s.Next(at.Add(time.Nanosecond)))
+}
+
+func TestAgent_InputSchedule(t *testing.T) {
+	c := newReloadConfig()
+	c.Agent.RoundInterval = true
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	input := newInput("input")
+	s, ok := a.inputSchedule(input).(*intervalSchedule)
+	require.True(t, ok)
+	assert.True(t, s.round)
+	assert.Equal(t, c.Agent.Interval.Duration, s.interval)
+
+	round := false
+	input.Config.RoundInterval = &round
+	input.Config.Interval = time.Minute
+	s, ok = a.inputSchedule(input).(*intervalSchedule)
+	require.True(t, ok)
+	assert.False(t, s.round)
+	assert.Equal(t, time.Minute, s.interval)
+
+	input.Config.Schedule = "@hourly"
+	assert.IsType(t, &cronSchedule{}, a.inputSchedule(input))
+}
+
+// hungInput blocks in Gather until release is closed, or until the gather is
+// canceled if cancelable is set.
+type hungInput struct {
+	release chan struct{}
+	gathers int32
+}
+
+func (i *hungInput) Description() string  { return "" }
+func (i *hungInput) SampleConfig() string { return "" }
+func (i *hungInput) Gather(acc telegraf.Accumulator) error {
+	atomic.AddInt32(&i.gathers, 1)
+	<-i.release
+	return nil
+}
+
+type cancelableInput struct {
+	hungInput
+}
+
+func (i *cancelableInput) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
+	select {
+	case <-i.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestGatherWithTimeout_Cancel(t *testing.T) {
+	input := models.NewRunningInput(&cancelableInput{
+		hungInput{release: make(chan struct{})},
+	}, &models.InputConfig{Name: "cancelable"})
+	input.GatherSkipped.Set(0)
+	acc := NewAccumulator(input, make(chan telegraf.Metric, 10))
+	sched := &intervalSchedule{interval: 50 * time.Millisecond, start: time.Now()}
+
+	following := sched.Next(time.Now().Add(time.Millisecond))
+	next := gatherWithTimeout(make(chan struct{}), input, acc,
+		10*time.Millisecond, sched, following)
+	assert.Equal(t, following, next)
+	assert.Equal(t, int64(0), input.GatherSkipped.Get())
+	_, err := input.LastGather()
+	assert.Error(t, err)
+}
+
+func TestGatherWithTimeout_Skip(t *testing.T) {
+	hung := &hungInput{release: make(chan struct{})}
+	input := models.NewRunningInput(hung, &models.InputConfig{Name: "hung"})
+	input.GatherSkipped.Set(0)
+	acc := NewAccumulator(input, make(chan telegraf.Metric, 10))
+	sched := &intervalSchedule{interval: 20 * time.Millisecond, start: time.Now()}
+
+	go func() {
+		time.Sleep(110 * time.Millisecond)
+		close(hung.release)
+	}()
+	following := sched.Next(time.Now().Add(time.Millisecond))
+	next := gatherWithTimeout(make(chan struct{}), input, acc,
+		10*time.Millisecond, sched, following)
+	assert.True(t, next.After(time.Now()))
+	assert.True(t, input.GatherSkipped.Get() >= 4)
+}
@@ -1,5 +1,7 @@
 package telegraf
 
+import "time"
+
 // Aggregator is an interface for implementing an Aggregator plugin.
 // the RunningAggregator wraps this interface and guarantees that
 // Add, Push, and Reset can not be called concurrently, so locking is not
@@ -20,3 +22,22 @@ type Aggregator interface {
 	// Reset resets the aggregators caches and aggregates.
 	Reset()
 }
+
+// SeriesExpirer is an interface that Aggregators keeping a cache per series
+// can implement to let the RunningAggregator limit the number of series they
+// track and remove the idle ones.
+type SeriesExpirer interface {
+	// Expire removes the cached aggregates of the series with the given
+	// HashID.
+	Expire(id uint64)
+}
+
+// DuePusher is an interface that Aggregators whose aggregates are complete
+// before the end of the period can implement to let the RunningAggregator
+// push each of them once the delay has elapsed after its time.
+type DuePusher interface {
+	// PushUntil pushes the aggregates of the metrics with a time up to the
+	// given one to the accumulator and removes them. It returns the earliest
+	// time of the aggregates left, or the zero time if none are left.
+	PushUntil(acc Accumulator, t time.Time) time.Time
+}
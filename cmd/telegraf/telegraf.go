@@ -35,6 +35,8 @@ var fTest = flag.Bool("test", false, "gather metrics, print them out, and exit")
 var fConfig = flag.String("config", "", "configuration file to load")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
+var fWatchConfig = flag.Bool("watch-config", false,
+	"reload the config when --config or --config-directory change")
 var fVersion = flag.Bool("version", false, "display the version")
 var fSampleConfig = flag.Bool("sample-config", false,
 	"print out full sample configuration")
@@ -76,6 +78,50 @@ func init() {
 
 var stop chan struct{}
 
+// loadConfig loads the config from --config and --config-directory. When
+// prev is not nil, the plugins of prev with an unchanged configuration are
+// reused by the new config.
+func loadConfig(
+	prev *config.Config,
+	inputFilters []string,
+	outputFilters []string,
+) (*config.Config, error) {
+	c := config.NewConfig()
+	c.OutputFilters = outputFilters
+	c.InputFilters = inputFilters
+	if prev != nil {
+		c.ReusePlugins(prev)
+	}
+	err := c.LoadConfig(*fConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if *fConfigDirectory != "" {
+		err = c.LoadDirectory(*fConfigDirectory)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !*fTest && len(c.Outputs) == 0 {
+		return nil, fmt.Errorf("Error: no outputs found, did you provide a valid config file?")
+	}
+	if len(c.Inputs) == 0 {
+		return nil, fmt.Errorf("Error: no inputs found, did you provide a valid config file?")
+	}
+
+	if int64(c.Agent.Interval.Duration) <= 0 {
+		return nil, fmt.Errorf("Agent interval must be positive, found %s",
+			c.Agent.Interval.Duration)
+	}
+
+	if int64(c.Agent.FlushInterval.Duration) <= 0 {
+		return nil, fmt.Errorf("Agent flush_interval must be positive; found %s",
+			c.Agent.Interval.Duration)
+	}
+	return c, nil
+}
+
 func reloadLoop(
 	stop chan struct{},
 	inputFilters []string,
@@ -89,37 +135,11 @@ func reloadLoop(
 		reload <- false
 
 		// If no other options are specified, load the config file and run.
-		c := config.NewConfig()
-		c.OutputFilters = outputFilters
-		c.InputFilters = inputFilters
-		err := c.LoadConfig(*fConfig)
+		c, err := loadConfig(nil, inputFilters, outputFilters)
 		if err != nil {
 			log.Fatal("E! " + err.Error())
 		}
 
-		if *fConfigDirectory != "" {
-			err = c.LoadDirectory(*fConfigDirectory)
-			if err != nil {
-				log.Fatal("E! " + err.Error())
-			}
-		}
-		if !*fTest && len(c.Outputs) == 0 {
-			log.Fatalf("E! Error: no outputs found, did you provide a valid config file?")
-		}
-		if len(c.Inputs) == 0 {
-			log.Fatalf("E! Error: no inputs found, did you provide a valid config file?")
-		}
-
-		if int64(c.Agent.Interval.Duration) <= 0 {
-			log.Fatalf("E! Agent interval must be positive, found %s",
-				c.Agent.Interval.Duration)
-		}
-
-		if int64(c.Agent.FlushInterval.Duration) <= 0 {
-			log.Fatalf("E! Agent flush_interval must be positive; found %s",
-				c.Agent.Interval.Duration)
-		}
-
 		ag, err := agent.NewAgent(c)
 		if err != nil {
 			log.Fatal("E! " + err.Error())
@@ -145,23 +165,44 @@ func reloadLoop(
 			log.Fatal("E! " + err.Error())
 		}
 
+		var watcher *config.Watcher
+		var changes <-chan struct{}
+		if *fWatchConfig {
+			watcher, err = config.NewWatcher(*fConfig, *fConfigDirectory)
+			if err != nil {
+				log.Printf("E! Unable to watch the config for changes: %s", err)
+			} else {
+				changes = watcher.Changes()
+			}
+		}
+
 		shutdown := make(chan struct{})
 		signals := make(chan os.Signal)
 		signal.Notify(signals, os.Interrupt, syscall.SIGHUP, syscall.SIGTERM)
 		go func() {
-			select {
-			case sig := <-signals:
-				if sig == os.Interrupt || sig == syscall.SIGTERM {
+			for {
+				select {
+				case sig := <-signals:
+					if sig == os.Interrupt || sig == syscall.SIGTERM {
+						close(shutdown)
+						return
+					}
+					if sig == syscall.SIGHUP {
+						log.Printf("I! Reloading Telegraf config\n")
+					}
+				case <-changes:
+					log.Printf("I! Config changed, reloading Telegraf config\n")
+				case <-stop:
 					close(shutdown)
+					return
 				}
-				if sig == syscall.SIGHUP {
-					log.Printf("I! Reloading Telegraf config\n")
+
+				if !reloadConfig(ag, inputFilters, outputFilters) {
 					<-reload
 					reload <- true
 					close(shutdown)
+					return
 				}
-			case <-stop:
-				close(shutdown)
 			}
 		}()
 
@@ -191,9 +232,64 @@ func reloadLoop(
 		}
 
 		ag.Run(shutdown)
+
+		if watcher != nil {
+			watcher.Close()
+		}
 	}
 }
 
+// reloadConfig loads the config again and applies the changes to the running
+// agent. It returns false if the agent needs to be restarted to apply them.
+// If the config is invalid, the agent keeps running with its current config.
+func reloadConfig(ag *agent.Agent, inputFilters, outputFilters []string) bool {
+	c, err := loadConfig(ag.Config, inputFilters, outputFilters)
+	if err != nil {
+		log.Printf("E! Not reloading invalid config: %s", err)
+		return true
+	}
+
+	err = ag.Reload(c)
+	if err == agent.ErrFullReload {
+		log.Printf("I! Agent settings changed, restarting all plugins\n")
+		return false
+	}
+	if err != nil {
+		log.Printf("E! Error reloading config: %s", err)
+	}
+	return true
+}
+
+// checkConfig loads the config like the agent would and prints the problems
+// found. It returns the exit code of the check, which is 1 if problems were
+// found.
+func checkConfig(inputFilters []string, outputFilters []string) int {
+	c := config.NewConfig()
+	c.OutputFilters = outputFilters
+	c.InputFilters = inputFilters
+	c.EnableCheck()
+
+	err := c.LoadConfig(*fConfig)
+	if err == nil && *fConfigDirectory != "" {
+		err = c.LoadDirectory(*fConfigDirectory)
+	}
+
+	problems := c.Problems()
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	if len(problems) > 0 {
+		fmt.Printf("Found %d problems\n", len(problems))
+		return 1
+	}
+	fmt.Println("No problems found")
+	return 0
+}
+
 func usageExit(rc int) {
 	fmt.Println(internal.Usage)
 	os.Exit(rc)
@@ -276,6 +372,9 @@ func main() {
 			fmt.Printf("Telegraf %s (git: %s %s)\n", displayVersion(), branch, commit)
 			return
 		case "config":
+			if len(args) > 1 && args[1] == "check" {
+				os.Exit(checkConfig(inputFilters, outputFilters))
+			}
 			config.PrintSampleConfig(
 				inputFilters,
 				outputFilters,
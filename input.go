@@ -1,5 +1,7 @@
 package telegraf
 
+import "context"
+
 type Input interface {
 	// SampleConfig returns the default configuration of the Input
 	SampleConfig() string
@@ -29,3 +31,13 @@ type ServiceInput interface {
 	// Stop stops the services and closes any necessary channels and connections
 	Stop()
 }
+
+// ContextInput is an Input whose gathers can be canceled, for example when they
+// exceed the gather_timeout of the input.
+type ContextInput interface {
+	Input
+
+	// GatherContext gathers like Gather, but aborts the gather and returns as
+	// soon as possible once ctx is done.
+	GatherContext(ctx context.Context, acc Accumulator) error
+}
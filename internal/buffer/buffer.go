@@ -12,34 +12,68 @@ var (
 	MetricsDropped = selfstat.Register("agent", "metrics_dropped", map[string]string{})
 )
 
-// Buffer is an object for storing metrics in a circular buffer.
-type Buffer struct {
-	buf chan telegraf.Metric
+// Buffer is a store for metrics that are waiting to be written to an output.
+type Buffer interface {
+	// IsEmpty returns true if Buffer is empty.
+	IsEmpty() bool
+
+	// Len returns the current length of the buffer.
+	Len() int
+
+	// Add adds metrics to the buffer. If the buffer is full, then the oldest
+	// metric(s) will be dropped.
+	Add(metrics ...telegraf.Metric)
+
+	// Batch removes and returns a batch of the oldest metrics of maximum
+	// length batchSize. It can be less than batchSize, if the length of the
+	// Buffer is less than batchSize.
+	Batch(batchSize int) []telegraf.Metric
+
+	// Accept acknowledges the batches taken since the last call, once they
+	// have been written or added back to a buffer. A persistent buffer keeps
+	// the metrics of the batches until then, so that they are replayed if
+	// telegraf stops while they are being written.
+	Accept()
+
+	// SetDropHandler sets a function that is called with each metric dropped
+	// because the buffer is full.
+	SetDropHandler(fn func(telegraf.Metric))
+
+	// Close releases any resources held by the buffer. Metrics remaining in
+	// a persistent buffer are kept and will be available again when the
+	// buffer is reopened.
+	Close() error
+}
+
+// MemoryBuffer is an object for storing metrics in a circular buffer.
+type MemoryBuffer struct {
+	buf    chan telegraf.Metric
+	dropFn func(telegraf.Metric)
 
 	mu sync.Mutex
 }
 
-// NewBuffer returns a Buffer
+// NewBuffer returns a MemoryBuffer
 //   size is the maximum number of metrics that Buffer will cache. If Add is
 //   called when the buffer is full, then the oldest metric(s) will be dropped.
-func NewBuffer(size int) *Buffer {
-	return &Buffer{
+func NewBuffer(size int) *MemoryBuffer {
+	return &MemoryBuffer{
 		buf: make(chan telegraf.Metric, size),
 	}
 }
 
 // IsEmpty returns true if Buffer is empty.
-func (b *Buffer) IsEmpty() bool {
+func (b *MemoryBuffer) IsEmpty() bool {
 	return len(b.buf) == 0
 }
 
 // Len returns the current length of the buffer.
-func (b *Buffer) Len() int {
+func (b *MemoryBuffer) Len() int {
 	return len(b.buf)
 }
 
 // Add adds metrics to the buffer.
-func (b *Buffer) Add(metrics ...telegraf.Metric) {
+func (b *MemoryBuffer) Add(metrics ...telegraf.Metric) {
 	for i, _ := range metrics {
 		MetricsWritten.Incr(1)
 		select {
@@ -47,9 +81,12 @@ func (b *Buffer) Add(metrics ...telegraf.Metric) {
 		default:
 			b.mu.Lock()
 			MetricsDropped.Incr(1)
-			<-b.buf
+			dropped := <-b.buf
 			b.buf <- metrics[i]
 			b.mu.Unlock()
+			if b.dropFn != nil {
+				b.dropFn(dropped)
+			}
 		}
 	}
 }
@@ -57,7 +94,7 @@ func (b *Buffer) Add(metrics ...telegraf.Metric) {
 // Batch returns a batch of metrics of size batchSize.
 // the batch will be of maximum length batchSize. It can be less than batchSize,
 // if the length of Buffer is less than batchSize.
-func (b *Buffer) Batch(batchSize int) []telegraf.Metric {
+func (b *MemoryBuffer) Batch(batchSize int) []telegraf.Metric {
 	b.mu.Lock()
 	n := min(len(b.buf), batchSize)
 	out := make([]telegraf.Metric, n)
@@ -68,6 +105,20 @@ func (b *Buffer) Batch(batchSize int) []telegraf.Metric {
 	return out
 }
 
+// Accept is a no-op for the in-memory buffer, whose batches are lost with
+// telegraf anyway.
+func (b *MemoryBuffer) Accept() {}
+
+// SetDropHandler sets a function called with each dropped metric.
+func (b *MemoryBuffer) SetDropHandler(fn func(telegraf.Metric)) {
+	b.dropFn = fn
+}
+
+// Close is a no-op for the in-memory buffer.
+func (b *MemoryBuffer) Close() error {
+	return nil
+}
+
 func min(a, b int) int {
 	if b < a {
 		return b
@@ -0,0 +1,547 @@
+package buffer
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+const segmentExt = ".wal"
+
+// DiskBuffer is a Buffer that persists metrics in a write-ahead log made of
+// segment files, so that buffered metrics survive a restart of telegraf.
+//
+// Metrics are appended to the newest segment and taken from the oldest one.
+// Only these two segments are held in memory, all others are only on disk.
+// A segment file is removed once all of its metrics have been taken by Batch
+// and the batches accepted, so that a batch being written is replayed if
+// telegraf stops before the write completes. Metrics taken from a partially
+// consumed segment are likewise replayed if telegraf exits without closing
+// the buffer, so delivery from a DiskBuffer is at-least-once.
+type DiskBuffer struct {
+	dir         string
+	limit       int
+	segmentSize int
+	maxBytes    int64
+
+	segments []*segment
+	nextID   uint64
+	// taken holds the segments whose metrics were all taken by batches not
+	// accepted yet, and pending is true while there are such batches.
+	taken   []*segment
+	pending bool
+	len      int
+	bytes    int64
+
+	dropFn  func(telegraf.Metric)
+	dropped []telegraf.Metric
+
+	mu sync.Mutex
+}
+
+// segment is a single file of the write-ahead log.
+type segment struct {
+	path string
+	// count is the number of metrics remaining in the segment.
+	count int
+	// consumed is the number of metrics taken from the segment that are
+	// still present in the file.
+	consumed int
+	// written is the number of metrics appended to an open segment.
+	written int
+	// size is the number of bytes the segment uses on disk.
+	size int64
+	// metrics holds the remaining metrics, nil when not loaded into memory.
+	metrics []telegraf.Metric
+	// volatile is set when the segment could not be written to disk, its
+	// metrics are only kept in memory.
+	volatile bool
+
+	open bool
+	file *os.File
+	enc  *gob.Encoder
+}
+
+// record is the on-disk representation of a metric.
+type record struct {
+	Name      string
+	Tags      map[string]string
+	Fields    map[string]interface{}
+	Time      time.Time
+	Type      telegraf.ValueType
+	Aggregate bool
+}
+
+// NewDiskBuffer returns a DiskBuffer storing its segments in dir. Metrics left
+// in dir by a previous run are loaded and available immediately.
+//   limit is the maximum number of metrics that the buffer will hold.
+//   segmentSize is the number of metrics written to each segment file.
+//   maxBytes is the maximum disk usage of the segments, 0 for no limit.
+// When either limit is exceeded, the oldest metric(s) will be dropped.
+func NewDiskBuffer(
+	dir string,
+	limit int,
+	segmentSize int,
+	maxBytes int64,
+) (*DiskBuffer, error) {
+	if segmentSize <= 0 || segmentSize > limit {
+		segmentSize = limit
+	}
+	b := &DiskBuffer{
+		dir:         dir,
+		limit:       limit,
+		segmentSize: segmentSize,
+		maxBytes:    maxBytes,
+	}
+
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+	if err := b.replay(); err != nil {
+		return nil, err
+	}
+	b.trim()
+	return b, nil
+}
+
+// replay loads the segments found in the buffer directory.
+func (b *DiskBuffer) replay() error {
+	paths, err := filepath.Glob(filepath.Join(b.dir, "*"+segmentExt))
+	if err != nil {
+		return err
+	}
+
+	ids := make(map[string]uint64, len(paths))
+	for _, path := range paths {
+		id, err := strconv.ParseUint(
+			strings.TrimSuffix(filepath.Base(path), segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		ids[path] = id
+	}
+	sort.Slice(paths, func(i, j int) bool { return ids[paths[i]] < ids[paths[j]] })
+
+	for _, path := range paths {
+		id, ok := ids[path]
+		if !ok {
+			continue
+		}
+		if id >= b.nextID {
+			b.nextID = id + 1
+		}
+
+		metrics, size, err := readSegment(path)
+		if err != nil {
+			log.Printf("W! Buffer segment %s is truncated, recovered %d metrics: %s",
+				path, len(metrics), err)
+		}
+		if len(metrics) == 0 {
+			os.Remove(path)
+			continue
+		}
+
+		s := &segment{
+			path:  path,
+			count: len(metrics),
+			size:  size,
+		}
+		// Keep the oldest segment in memory, it is the next one read.
+		if len(b.segments) == 0 {
+			s.metrics = metrics
+		}
+		b.segments = append(b.segments, s)
+		b.len += s.count
+		b.bytes += s.size
+	}
+	return nil
+}
+
+// IsEmpty returns true if Buffer is empty.
+func (b *DiskBuffer) IsEmpty() bool {
+	return b.Len() == 0
+}
+
+// Len returns the current length of the buffer.
+func (b *DiskBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.len
+}
+
+// Add adds metrics to the buffer.
+func (b *DiskBuffer) Add(metrics ...telegraf.Metric) {
+	b.mu.Lock()
+	for _, m := range metrics {
+		MetricsWritten.Incr(1)
+		b.append(m)
+	}
+	b.trim()
+	dropped := b.dropped
+	b.dropped = nil
+	b.mu.Unlock()
+
+	if b.dropFn != nil {
+		for _, m := range dropped {
+			b.dropFn(m)
+		}
+	}
+}
+
+// SetDropHandler sets a function called with each dropped metric.
+func (b *DiskBuffer) SetDropHandler(fn func(telegraf.Metric)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dropFn = fn
+}
+
+// Batch returns a batch of metrics of size batchSize.
+// the batch will be of maximum length batchSize. It can be less than batchSize,
+// if the length of Buffer is less than batchSize.
+func (b *DiskBuffer) Batch(batchSize int) []telegraf.Metric {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out := make([]telegraf.Metric, 0, min(b.len, batchSize))
+	for len(out) < batchSize && len(b.segments) > 0 {
+		s := b.segments[0]
+		if !b.load(s) {
+			continue
+		}
+
+		n := min(batchSize-len(out), len(s.metrics))
+		out = append(out, s.metrics[:n]...)
+		s.metrics = s.metrics[n:]
+		s.count -= n
+		s.consumed += n
+		b.len -= n
+		b.pending = true
+		if s.count == 0 {
+			b.takeHead()
+		}
+	}
+	return out
+}
+
+// Accept removes the files of the segments whose metrics were all taken by
+// the batches accepted.
+func (b *DiskBuffer) Accept() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, s := range b.taken {
+		s.remove()
+	}
+	b.taken = nil
+	b.pending = false
+}
+
+// Close closes the open segment and rewrites the oldest segment without the
+// metrics already taken from it, so that they are not replayed. If batches
+// were not accepted, the segments are left as they are for their metrics to
+// be replayed.
+func (b *DiskBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var err error
+	for _, s := range b.segments {
+		if cerr := s.close(); cerr != nil {
+			err = cerr
+		}
+	}
+	if !b.pending && len(b.segments) > 0 && b.segments[0].consumed > 0 {
+		if rerr := b.rewrite(b.segments[0]); rerr != nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+// append writes a metric to the newest segment, opening a new one if needed.
+func (b *DiskBuffer) append(m telegraf.Metric) {
+	s := b.tail()
+	if s.enc != nil {
+		before := s.size
+		if err := s.enc.Encode(newRecord(m)); err != nil {
+			log.Printf("E! Unable to write to buffer segment %s, keeping "+
+				"metrics in memory: %s", s.path, err)
+			s.volatile = true
+			s.close()
+		}
+		b.bytes += s.size - before
+	}
+	s.metrics = append(s.metrics, m)
+	s.count++
+	s.written++
+	b.len++
+
+	if s.written >= b.segmentSize {
+		s.close()
+		s.open = false
+		// Unload the segment unless it will be read next, or only exists in
+		// memory.
+		if s != b.segments[0] && !s.volatile {
+			s.metrics = nil
+		}
+	}
+}
+
+// tail returns the open segment, creating it if necessary.
+func (b *DiskBuffer) tail() *segment {
+	if n := len(b.segments); n > 0 && b.segments[n-1].open {
+		return b.segments[n-1]
+	}
+
+	s := &segment{
+		path:    filepath.Join(b.dir, fmt.Sprintf("%020d%s", b.nextID, segmentExt)),
+		metrics: []telegraf.Metric{},
+		open:    true,
+	}
+	b.nextID++
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+	if err != nil {
+		log.Printf("E! Unable to create buffer segment, keeping metrics in "+
+			"memory: %s", err)
+		s.path = ""
+		s.volatile = true
+	} else {
+		s.file = f
+		s.enc = gob.NewEncoder(&countingWriter{w: f, n: &s.size})
+	}
+	b.segments = append(b.segments, s)
+	return s
+}
+
+// trim drops the oldest metrics until the buffer is within its limits.
+func (b *DiskBuffer) trim() {
+	for b.len > b.limit && len(b.segments) > 0 {
+		s := b.segments[0]
+		if !b.load(s) {
+			continue
+		}
+		n := min(b.len-b.limit, len(s.metrics))
+		MetricsDropped.Incr(int64(n))
+		if b.dropFn != nil {
+			b.dropped = append(b.dropped, s.metrics[:n]...)
+		}
+		s.metrics = s.metrics[n:]
+		s.count -= n
+		s.consumed += n
+		b.len -= n
+		if s.count == 0 {
+			b.removeHead()
+		}
+	}
+
+	// The open segment is never dropped for size, so that the buffer always
+	// accepts new metrics.
+	for b.maxBytes > 0 && b.bytes > b.maxBytes && len(b.segments) > 1 {
+		s := b.segments[0]
+		if b.dropFn != nil && b.load(s) {
+			b.dropped = append(b.dropped, s.metrics...)
+		}
+		MetricsDropped.Incr(int64(s.count))
+		b.removeHead()
+	}
+}
+
+// load reads the metrics of a segment into memory. If the segment can not be
+// read it is removed and false is returned.
+func (b *DiskBuffer) load(s *segment) bool {
+	if s.metrics != nil {
+		return true
+	}
+
+	metrics, _, err := readSegment(s.path)
+	if err != nil {
+		log.Printf("E! Unable to read buffer segment %s: %s", s.path, err)
+	}
+	// Skip the metrics consumed before the segment was unloaded.
+	if s.consumed < len(metrics) {
+		metrics = metrics[s.consumed:]
+	} else {
+		metrics = metrics[:0]
+	}
+	if len(metrics) < s.count {
+		MetricsDropped.Incr(int64(s.count - len(metrics)))
+		b.len -= s.count - len(metrics)
+		s.count = len(metrics)
+	}
+	s.metrics = metrics
+
+	if s.count == 0 {
+		b.removeHead()
+		return false
+	}
+	return true
+}
+
+// removeHead removes the oldest segment and its file.
+func (b *DiskBuffer) removeHead() {
+	b.popHead().remove()
+}
+
+// takeHead removes the oldest segment, whose metrics were all taken, keeping
+// its file until the batches are accepted.
+func (b *DiskBuffer) takeHead() {
+	s := b.popHead()
+	s.metrics = nil
+	b.taken = append(b.taken, s)
+}
+
+// popHead removes the oldest segment from the buffer and returns it.
+func (b *DiskBuffer) popHead() *segment {
+	s := b.segments[0]
+	s.close()
+	b.len -= s.count
+	b.bytes -= s.size
+	b.segments[0] = nil
+	b.segments = b.segments[1:]
+
+	// Keep the next segment in memory, it is the next one read.
+	if len(b.segments) > 0 {
+		b.load(b.segments[0])
+	}
+	return s
+}
+
+// rewrite replaces the file of a segment with only its remaining metrics.
+func (b *DiskBuffer) rewrite(s *segment) error {
+	if s.path == "" || !b.load(s) {
+		return nil
+	}
+
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	var size int64
+	w := bufio.NewWriter(&countingWriter{w: f, n: &size})
+	enc := gob.NewEncoder(w)
+	for _, m := range s.metrics {
+		if err = enc.Encode(newRecord(m)); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err = os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	b.bytes += size - s.size
+	s.size = size
+	s.consumed = 0
+	return nil
+}
+
+// remove removes the file of a segment.
+func (s *segment) remove() {
+	if s.path == "" {
+		return
+	}
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		log.Printf("E! Unable to remove buffer segment %s: %s", s.path, err)
+	}
+}
+
+// close closes the file of an open segment.
+func (s *segment) close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Sync()
+	if cerr := s.file.Close(); err == nil {
+		err = cerr
+	}
+	s.file = nil
+	s.enc = nil
+	return err
+}
+
+// readSegment reads all metrics from a segment file. If the file ends with an
+// incomplete record, the metrics read up to that point are returned along
+// with the error.
+func readSegment(path string) ([]telegraf.Metric, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var size int64
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	metrics := []telegraf.Metric{}
+	dec := gob.NewDecoder(bufio.NewReader(f))
+	for {
+		var r record
+		err := dec.Decode(&r)
+		if err == io.EOF {
+			return metrics, size, nil
+		}
+		if err != nil {
+			return metrics, size, err
+		}
+
+		m, err := metric.New(r.Name, r.Tags, r.Fields, r.Time, r.Type)
+		if err != nil {
+			return metrics, size, err
+		}
+		if r.Aggregate {
+			m.SetAggregate(true)
+		}
+		metrics = append(metrics, m)
+	}
+}
+
+func newRecord(m telegraf.Metric) *record {
+	return &record{
+		Name:      m.Name(),
+		Tags:      m.Tags(),
+		Fields:    m.Fields(),
+		Time:      m.Time(),
+		Type:      m.Type(),
+		Aggregate: m.IsAggregate(),
+	}
+}
+
+// countingWriter counts the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,197 @@
+package buffer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	return dir
+}
+
+func names(metrics []telegraf.Metric) []string {
+	out := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		out = append(out, m.Name())
+	}
+	return out
+}
+
+func TestDiskBufferBasicFuncs(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	MetricsDropped.Set(0)
+	MetricsWritten.Set(0)
+
+	b, err := NewDiskBuffer(dir, 10, 2, 0)
+	require.NoError(t, err)
+	assert.True(t, b.IsEmpty())
+
+	b.Add(metricList...)
+	assert.False(t, b.IsEmpty())
+	assert.Equal(t, 5, b.Len())
+	assert.Equal(t, int64(5), MetricsWritten.Get())
+
+	batch := b.Batch(3)
+	assert.Equal(t, []string{"mymetric1", "mymetric2", "mymetric3"}, names(batch))
+	assert.Equal(t, 2, b.Len())
+
+	batch = b.Batch(10)
+	assert.Equal(t, []string{"mymetric4", "mymetric5"}, names(batch))
+	assert.True(t, b.IsEmpty())
+	assert.Zero(t, MetricsDropped.Get())
+
+	b.Accept()
+	require.NoError(t, b.Close())
+	segments, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	require.NoError(t, err)
+	assert.Empty(t, segments)
+}
+
+func TestDiskBufferDroppingMetrics(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	MetricsDropped.Set(0)
+	MetricsWritten.Set(0)
+
+	b, err := NewDiskBuffer(dir, 10, 3, 0)
+	require.NoError(t, err)
+	defer b.Close()
+
+	b.Add(metricList...)
+	b.Add(metricList...)
+	b.Add(metricList...)
+	assert.Equal(t, 10, b.Len())
+	assert.Equal(t, int64(5), MetricsDropped.Get())
+	assert.Equal(t, int64(15), MetricsWritten.Get())
+
+	batch := b.Batch(10)
+	assert.Equal(t, names(append(metricList, metricList...)), names(batch))
+}
+
+func TestDiskBufferReplay(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b, err := NewDiskBuffer(dir, 100, 2, 0)
+	require.NoError(t, err)
+
+	m, _ := metric.New("cpu",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{"usage": 42.0, "count": uint64(7), "up": true},
+		time.Unix(42, 0),
+		telegraf.Counter,
+	)
+	b.Add(m)
+	b.Add(metricList...)
+	b.Batch(2)
+	b.Accept()
+	require.NoError(t, b.Close())
+
+	b, err = NewDiskBuffer(dir, 100, 2, 0)
+	require.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, 4, b.Len())
+
+	batch := b.Batch(10)
+	assert.Equal(t,
+		[]string{"mymetric2", "mymetric3", "mymetric4", "mymetric5"},
+		names(batch))
+	assert.Equal(t, metricList[1].Fields(), batch[0].Fields())
+	assert.Equal(t, metricList[1].Time().UnixNano(), batch[0].Time().UnixNano())
+	b.Accept()
+
+	b.Add(m)
+	require.NoError(t, b.Close())
+	b, err = NewDiskBuffer(dir, 100, 2, 0)
+	require.NoError(t, err)
+	defer b.Close()
+
+	batch = b.Batch(10)
+	require.Len(t, batch, 1)
+	assert.Equal(t, m.Name(), batch[0].Name())
+	assert.Equal(t, m.Tags(), batch[0].Tags())
+	assert.Equal(t, m.Fields(), batch[0].Fields())
+	assert.True(t, m.Time().Equal(batch[0].Time()))
+	assert.Equal(t, telegraf.Counter, batch[0].Type())
+}
+
+func TestDiskBufferReplayUnaccepted(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b, err := NewDiskBuffer(dir, 100, 2, 0)
+	require.NoError(t, err)
+	b.Add(metricList...)
+	b.Batch(3)
+
+	// The batch being written is replayed after a crash.
+	b2, err := NewDiskBuffer(dir, 100, 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 5, b2.Len())
+
+	// and after a shutdown before it is accepted.
+	require.NoError(t, b.Close())
+	b, err = NewDiskBuffer(dir, 100, 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 5, b.Len())
+
+	batch := b.Batch(3)
+	assert.Equal(t, []string{"mymetric1", "mymetric2", "mymetric3"}, names(batch))
+	b.Accept()
+	require.NoError(t, b.Close())
+	b, err = NewDiskBuffer(dir, 100, 2, 0)
+	require.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, []string{"mymetric4", "mymetric5"}, names(b.Batch(10)))
+}
+
+func TestDiskBufferReplayTruncatedSegment(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b, err := NewDiskBuffer(dir, 100, 10, 0)
+	require.NoError(t, err)
+	b.Add(metricList...)
+	require.NoError(t, b.Close())
+
+	segments, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	info, err := os.Stat(segments[0])
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(segments[0], info.Size()-1))
+
+	b, err = NewDiskBuffer(dir, 100, 10, 0)
+	require.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, 4, b.Len())
+	assert.Equal(t, names(metricList[:4]), names(b.Batch(10)))
+}
+
+func TestDiskBufferMaxBytes(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	MetricsDropped.Set(0)
+
+	b, err := NewDiskBuffer(dir, 100, 1, 1)
+	require.NoError(t, err)
+	defer b.Close()
+
+	// Only the newest segment is kept when over the size limit.
+	b.Add(metricList...)
+	assert.Equal(t, 1, b.Len())
+	assert.Equal(t, int64(4), MetricsDropped.Get())
+	assert.Equal(t, []string{"mymetric5"}, names(b.Batch(10)))
+}
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
+)
+
+// durationKeys are the keys of the settings common to all plugins of a kind
+// that hold a duration.
+var durationKeys = []string{
+	"interval",
+	"collection_jitter",
+	"collection_offset",
+	"gather_timeout",
+	"flush_interval",
+	"flush_jitter",
+	"write_timeout",
+	"period",
+	"delay",
+}
+
+// filterKeys are the keys of the measurement filters holding glob patterns.
+var filterKeys = []string{
+	"namepass",
+	"namedrop",
+	"fieldpass",
+	"fielddrop",
+	"pass",
+	"drop",
+	"tagexclude",
+	"taginclude",
+}
+
+// Problem is an issue found in a config file while checking it.
+type Problem struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+}
+
+// checker collects the problems found while loading a config.
+type checker struct {
+	file     string
+	problems []Problem
+}
+
+func (ck *checker) add(line int, format string, a ...interface{}) {
+	ck.problems = append(ck.problems, Problem{
+		File:    ck.file,
+		Line:    line,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+// EnableCheck makes LoadConfig and LoadDirectory report the problems of the
+// plugins through Problems instead of failing on the first one. Besides the
+// errors that prevent loading a plugin, this reports the unused keys, the
+// invalid durations, filter patterns and data formats. Secret references
+// are not resolved, only their providers are checked, and the plugins are
+// not initialized, so that checking has no side effects.
+func (c *Config) EnableCheck() {
+	c.checker = &checker{}
+}
+
+// Problems returns the problems found while loading the config, ordered by
+// file and line.
+func (c *Config) Problems() []Problem {
+	if c.checker == nil {
+		return nil
+	}
+	problems := c.checker.problems
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].File != problems[j].File {
+			return problems[i].File < problems[j].File
+		}
+		return problems[i].Line < problems[j].Line
+	})
+	return problems
+}
+
+// unmarshalTable sets the fields of v from tbl. When checking the config, the
+// keys without a matching field are reported as unused.
+func (c *Config) unmarshalTable(name string, tbl *ast.Table, v interface{}) error {
+	if c.checker == nil {
+		return toml.UnmarshalTable(tbl, v)
+	}
+
+	cfg := toml.DefaultConfig
+	cfg.MissingField = func(typ reflect.Type, key string) error {
+		c.checker.add(keyLine(tbl, key), "%s: unused key %q", name, key)
+		return nil
+	}
+	return cfg.UnmarshalTable(tbl, v)
+}
+
+// checkPlugin reports the invalid settings common to all plugins in the table
+// of a plugin, and removes them so that loading the plugin can find further
+// problems. The name of the plugin is prefixed by its kind, e.g. inputs.cpu.
+func (ck *checker) checkPlugin(name string, tbl *ast.Table, plugin interface{}) {
+	ck.checkSecrets(name, tbl)
+
+	for _, key := range durationKeys {
+		kv, ok := tbl.Fields[key].(*ast.KeyValue)
+		if !ok {
+			continue
+		}
+		if str, ok := kv.Value.(*ast.String); ok {
+			if _, err := time.ParseDuration(str.Value); err != nil {
+				ck.add(kv.Line, "%s: invalid duration for %s: %s", name, key, err)
+				delete(tbl.Fields, key)
+			}
+		}
+	}
+
+	ck.checkFilters(name, tbl)
+	for _, key := range []string{"tagpass", "tagdrop"} {
+		if subtbl, ok := tbl.Fields[key].(*ast.Table); ok {
+			ck.checkFilters(name+"."+key, subtbl)
+		}
+	}
+
+	kv, ok := tbl.Fields["data_format"].(*ast.KeyValue)
+	if !ok {
+		return
+	}
+	// Build the parser or serializer from a copy, as doing so removes the
+	// keys it uses from the table.
+	fields := make(map[string]interface{}, len(tbl.Fields))
+	for k, v := range tbl.Fields {
+		fields[k] = v
+	}
+	pluginName := name[strings.Index(name, ".")+1:]
+	var err error
+	switch plugin.(type) {
+	case parsers.ParserInput:
+		_, err = buildParser(pluginName, &ast.Table{Fields: fields})
+	case serializers.SerializerOutput:
+		_, err = buildSerializer(pluginName, &ast.Table{Fields: fields})
+	default:
+		return
+	}
+	if err != nil {
+		ck.add(kv.Line, "%s: invalid data_format options: %s", name, err)
+		delete(tbl.Fields, "data_format")
+	}
+}
+
+// checkFilters reports and removes the invalid glob patterns of the filter
+// keys in tbl.
+func (ck *checker) checkFilters(name string, tbl *ast.Table) {
+	for key, node := range tbl.Fields {
+		kv, ok := node.(*ast.KeyValue)
+		if !ok {
+			continue
+		}
+		if !isTagFilterTable(name) && !sliceContains(key, filterKeys) {
+			continue
+		}
+		ary, ok := kv.Value.(*ast.Array)
+		if !ok {
+			continue
+		}
+		for _, elem := range ary.Value {
+			str, ok := elem.(*ast.String)
+			if !ok {
+				continue
+			}
+			if _, err := filter.Compile([]string{str.Value}); err != nil {
+				ck.add(kv.Line, "%s: invalid pattern %q for %s: %s", name,
+					str.Value, key, err)
+				delete(tbl.Fields, key)
+				break
+			}
+		}
+	}
+}
+
+// checkSecrets reports the secret references in the string values of tbl
+// with an unknown provider. They are kept as is when loading the config, but
+// are likely misspelled references.
+func (ck *checker) checkSecrets(name string, tbl *ast.Table) {
+	walkStrings(tbl, func(kv *ast.KeyValue, str *ast.String) error {
+		for _, match := range secretRefRe.FindAllStringSubmatch(str.Value, -1) {
+			if match[1] == "" {
+				continue
+			}
+			if _, ok := lookupSecretProvider(match[1]); !ok {
+				ck.add(kv.Line, "%s: unknown secret provider %q in %s, "+
+					"write @@{ for a literal @{", name, match[1], match[0])
+			}
+		}
+		return nil
+	})
+}
+
+// isTagFilterTable returns true if name is the name of a tagpass or tagdrop
+// table, whose keys are tag names.
+func isTagFilterTable(name string) bool {
+	return strings.HasSuffix(name, ".tagpass") ||
+		strings.HasSuffix(name, ".tagdrop")
+}
+
+// keyLine returns the line of the key in tbl or its subtables, or the line of
+// tbl if the key is not found.
+func keyLine(tbl *ast.Table, key string) int {
+	if kv, ok := tbl.Fields[key].(*ast.KeyValue); ok {
+		return kv.Line
+	}
+	for _, node := range tbl.Fields {
+		switch node := node.(type) {
+		case *ast.Table:
+			if _, ok := node.Fields[key]; ok {
+				return keyLine(node, key)
+			}
+		case []*ast.Table:
+			for _, t := range node {
+				if _, ok := t.Fields[key]; ok {
+					return keyLine(t, key)
+				}
+			}
+		}
+	}
+	return tbl.Line
+}
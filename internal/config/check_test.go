@@ -0,0 +1,62 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Check(t *testing.T) {
+	c := NewConfig()
+	c.EnableCheck()
+	require.NoError(t, c.LoadConfig("./testdata/check.toml"))
+
+	var lines []int
+	for _, p := range c.Problems() {
+		assert.Equal(t, "./testdata/check.toml", p.File)
+		lines = append(lines, p.Line)
+	}
+	assert.Equal(t, []int{2, 6, 7, 8, 10, 14, 17, 18}, lines, "%v",
+		c.Problems())
+
+	// The plugins are still loaded without their invalid settings.
+	assert.Len(t, c.Inputs, 2)
+	assert.Len(t, c.Outputs, 1)
+}
+
+func TestConfig_CheckValid(t *testing.T) {
+	c := NewConfig()
+	c.EnableCheck()
+	require.NoError(t, c.LoadConfig("./testdata/single_plugin.toml"))
+	assert.Empty(t, c.Problems())
+}
+
+func TestConfig_CheckWithoutSideEffects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "check")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bufferPath := filepath.Join(dir, "buffer")
+	conf := filepath.Join(dir, "telegraf.conf")
+	require.NoError(t, ioutil.WriteFile(conf, []byte(`
+[[outputs.file]]
+  buffer_path = "`+bufferPath+`"
+`), 0644))
+
+	c := NewConfig()
+	c.EnableCheck()
+	require.NoError(t, c.LoadConfig(conf))
+	assert.Empty(t, c.Problems())
+	_, err = os.Stat(bufferPath)
+	assert.True(t, os.IsNotExist(err))
+
+	// Init is not run when checking.
+	c = NewConfig()
+	c.EnableCheck()
+	require.NoError(t, c.LoadConfig("./testdata/init_invalid.toml"))
+	assert.Empty(t, c.Problems())
+}
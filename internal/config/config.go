@@ -19,6 +19,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/cron"
 	"github.com/influxdata/telegraf/internal/models"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 	"github.com/influxdata/telegraf/plugins/inputs"
@@ -62,6 +63,17 @@ type Config struct {
 	Aggregators []*models.RunningAggregator
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors models.RunningProcessors
+
+	// previous holds the plugins of a previously loaded configuration by ID,
+	// they are used in place of new plugins with the same ID.
+	previous map[string][]interface{}
+
+	// checker collects the problems of the config if checking is enabled.
+	checker *checker
+
+	// secrets maps the values holding resolved secrets to their redacted
+	// form.
+	secrets map[string]string
 }
 
 func NewConfig() *Config {
@@ -83,6 +95,42 @@ func NewConfig() *Config {
 	return c
 }
 
+// ReusePlugins makes c use the plugins of prev in place of new plugins loaded
+// from identical configuration, so that they keep their state, such as the
+// buffered metrics of outputs, when the configuration is reloaded. It must be
+// called before loading the configuration.
+func (c *Config) ReusePlugins(prev *Config) {
+	c.previous = make(map[string][]interface{})
+	add := func(id string, plugin interface{}) {
+		if id != "" {
+			c.previous[id] = append(c.previous[id], plugin)
+		}
+	}
+	for _, p := range prev.Inputs {
+		add(p.ID, p)
+	}
+	for _, p := range prev.Outputs {
+		add(p.ID, p)
+	}
+	for _, p := range prev.Aggregators {
+		add(p.ID, p)
+	}
+	for _, p := range prev.Processors {
+		add(p.ID, p)
+	}
+}
+
+// previousPlugin returns an unused plugin of the previous configuration with
+// the given ID, or nil if there is none.
+func (c *Config) previousPlugin(id string) interface{} {
+	plugins := c.previous[id]
+	if len(plugins) == 0 {
+		return nil
+	}
+	c.previous[id] = plugins[1:]
+	return plugins[0]
+}
+
 type AgentConfig struct {
 	// Interval at which to gather information
 	Interval internal.Duration
@@ -145,6 +193,10 @@ type AgentConfig struct {
 	Quiet        bool
 	Hostname     string
 	OmitHostname bool
+
+	// AdminAddress is the address of the HTTP admin API, which is disabled
+	// when empty. It must be a loopback address.
+	AdminAddress string
 }
 
 // Inputs returns a list of strings of the configured inputs.
@@ -276,6 +328,10 @@ var header = `# Telegraf Configuration
   ## If set to true, do no set the "host" tag in the telegraf agent.
   omit_hostname = false
 
+  ## Address of the HTTP admin API, which lists the loaded plugins and their
+  ## status. Disabled when empty, only loopback addresses are allowed.
+  # admin_address = "localhost:8093"
+
 
 ###############################################################################
 #                            OUTPUT PLUGINS                                   #
@@ -595,6 +651,23 @@ func (c *Config) LoadConfig(path string) error {
 	if err != nil {
 		return fmt.Errorf("Error parsing %s, %s", path, err)
 	}
+	if c.checker != nil {
+		c.checker.file = path
+	}
+
+	// add adds the plugin with the given name from table t using addPlugin.
+	// When checking the config, errors are reported as problems instead.
+	add := func(addPlugin func(string, *ast.Table) error, name string, t *ast.Table) error {
+		err := addPlugin(name, t)
+		if err == nil {
+			return nil
+		}
+		if c.checker != nil {
+			c.checker.add(t.Line, "%s", err)
+			return nil
+		}
+		return fmt.Errorf("Error parsing %s, %s", path, err)
+	}
 
 	// Parse tags tables first:
 	for _, tableName := range []string{"tags", "global_tags"} {
@@ -616,7 +689,7 @@ func (c *Config) LoadConfig(path string) error {
 		if !ok {
 			return fmt.Errorf("%s: invalid configuration", path)
 		}
-		if err = toml.UnmarshalTable(subTable, c.Agent); err != nil {
+		if err = c.unmarshalTable("agent", subTable, c.Agent); err != nil {
 			log.Printf("E! Could not parse [agent] config\n")
 			return fmt.Errorf("Error parsing %s, %s", path, err)
 		}
@@ -636,13 +709,13 @@ func (c *Config) LoadConfig(path string) error {
 				switch pluginSubTable := pluginVal.(type) {
 				// legacy [outputs.influxdb] support
 				case *ast.Table:
-					if err = c.addOutput(pluginName, pluginSubTable); err != nil {
-						return fmt.Errorf("Error parsing %s, %s", path, err)
+					if err = add(c.addOutput, pluginName, pluginSubTable); err != nil {
+						return err
 					}
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addOutput(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", path, err)
+						if err = add(c.addOutput, pluginName, t); err != nil {
+							return err
 						}
 					}
 				default:
@@ -655,13 +728,13 @@ func (c *Config) LoadConfig(path string) error {
 				switch pluginSubTable := pluginVal.(type) {
 				// legacy [inputs.cpu] support
 				case *ast.Table:
-					if err = c.addInput(pluginName, pluginSubTable); err != nil {
-						return fmt.Errorf("Error parsing %s, %s", path, err)
+					if err = add(c.addInput, pluginName, pluginSubTable); err != nil {
+						return err
 					}
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addInput(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", path, err)
+						if err = add(c.addInput, pluginName, t); err != nil {
+							return err
 						}
 					}
 				default:
@@ -674,8 +747,8 @@ func (c *Config) LoadConfig(path string) error {
 				switch pluginSubTable := pluginVal.(type) {
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addProcessor(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", path, err)
+						if err = add(c.addProcessor, pluginName, t); err != nil {
+							return err
 						}
 					}
 				default:
@@ -688,8 +761,8 @@ func (c *Config) LoadConfig(path string) error {
 				switch pluginSubTable := pluginVal.(type) {
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addAggregator(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", path, err)
+						if err = add(c.addAggregator, pluginName, t); err != nil {
+							return err
 						}
 					}
 				default:
@@ -700,8 +773,8 @@ func (c *Config) LoadConfig(path string) error {
 		// Assume it's an input input for legacy config file support if no other
 		// identifiers are present
 		default:
-			if err = c.addInput(name, subTable); err != nil {
-				return fmt.Errorf("Error parsing %s, %s", path, err)
+			if err = add(c.addInput, name, subTable); err != nil {
+				return err
 			}
 		}
 	}
@@ -747,61 +820,225 @@ func parseFile(fpath string) (*ast.Table, error) {
 	return toml.Parse(contents)
 }
 
+// initPlugin initializes the plugin if it implements telegraf.Initializer,
+// once its configuration is set.
+func (c *Config) initPlugin(name string, plugin interface{}) error {
+	// Init may have side effects, and the secrets it may need are not
+	// resolved when checking the config.
+	if c.checker != nil {
+		return nil
+	}
+	if p, ok := plugin.(telegraf.Initializer); ok {
+		if err := p.Init(); err != nil {
+			return fmt.Errorf("Error initializing %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// pluginID returns a canonical representation of the configuration of a
+// plugin, independent of the formatting and order of its settings. It is used
+// to find the plugins whose configuration changed when reloading.
+func pluginID(name string, table *ast.Table) string {
+	var buf bytes.Buffer
+	buf.WriteString(name)
+	writePluginID(&buf, table)
+	return buf.String()
+}
+
+func writePluginID(buf *bytes.Buffer, node interface{}) {
+	switch n := node.(type) {
+	case *ast.Table:
+		keys := make([]string, 0, len(n.Fields))
+		for k := range n.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteString("{")
+		for _, k := range keys {
+			buf.WriteString(strconv.Quote(k) + "=")
+			writePluginID(buf, n.Fields[k])
+			buf.WriteString(",")
+		}
+		buf.WriteString("}")
+	case []*ast.Table:
+		buf.WriteString("[")
+		for _, t := range n {
+			writePluginID(buf, t)
+			buf.WriteString(",")
+		}
+		buf.WriteString("]")
+	case *ast.KeyValue:
+		writePluginID(buf, n.Value)
+	case *ast.Array:
+		buf.WriteString("[")
+		for _, v := range n.Value {
+			writePluginID(buf, v)
+			buf.WriteString(",")
+		}
+		buf.WriteString("]")
+	case *ast.String:
+		buf.WriteString(strconv.Quote(n.Value))
+	case ast.Value:
+		buf.WriteString(n.Source())
+	}
+}
+
 func (c *Config) addAggregator(name string, table *ast.Table) error {
+	if c.checker == nil {
+		if err := c.resolveSecrets(table); err != nil {
+			return fmt.Errorf("Error resolving secrets of aggregator %s: %s", name, err)
+		}
+	}
+	id := pluginID("aggregators."+name, table)
+	if ra, ok := c.previousPlugin(id).(*models.RunningAggregator); ok {
+		c.Aggregators = append(c.Aggregators, ra)
+		return nil
+	}
+
 	creator, ok := aggregators.Aggregators[name]
 	if !ok {
 		return fmt.Errorf("Undefined but requested aggregator: %s", name)
 	}
 	aggregator := creator()
+	if c.checker != nil {
+		c.checker.checkPlugin("aggregators."+name, table, aggregator)
+	}
 
 	conf, err := buildAggregator(name, table)
 	if err != nil {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, aggregator); err != nil {
+	if err := c.unmarshalTable("aggregators."+name, table, aggregator); err != nil {
 		return err
 	}
+	if err := c.initPlugin("aggregators."+name, aggregator); err != nil {
+		return err
+	}
+
+	if _, ok := aggregator.(telegraf.SeriesExpirer); !ok && conf.LimitsSeries() {
+		return fmt.Errorf("max_series and series_expiry are not supported "+
+			"by aggregator %s", name)
+	}
 
-	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(aggregator, conf))
+	ra := models.NewRunningAggregator(aggregator, conf)
+	ra.ID = id
+	c.Aggregators = append(c.Aggregators, ra)
 	return nil
 }
 
 func (c *Config) addProcessor(name string, table *ast.Table) error {
+	if c.checker == nil {
+		if err := c.resolveSecrets(table); err != nil {
+			return fmt.Errorf("Error resolving secrets of processor %s: %s", name, err)
+		}
+	}
+	id := pluginID("processors."+name, table)
+	if rf, ok := c.previousPlugin(id).(*models.RunningProcessor); ok {
+		c.Processors = append(c.Processors, rf)
+		return nil
+	}
+
+	rf, err := c.newRunningProcessor(name, "", table)
+	if err != nil {
+		return err
+	}
+	rf.ID = id
+
+	c.Processors = append(c.Processors, rf)
+	return nil
+}
+
+// newRunningProcessor creates the processor plugin with the given name,
+// configured from the ast.Table, and wraps it in a models.RunningProcessor.
+// The output is the name of the output the processor is applied to, if any.
+func (c *Config) newRunningProcessor(
+	name string,
+	output string,
+	table *ast.Table,
+) (*models.RunningProcessor, error) {
 	creator, ok := processors.Processors[name]
 	if !ok {
-		return fmt.Errorf("Undefined but requested processor: %s", name)
+		return nil, fmt.Errorf("Undefined but requested processor: %s", name)
 	}
 	processor := creator()
+	if c.checker != nil {
+		c.checker.checkPlugin("processors."+name, table, processor)
+	}
 
 	processorConfig, err := buildProcessor(name, table)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	processorConfig.Output = output
 
-	if err := toml.UnmarshalTable(table, processor); err != nil {
-		return err
+	if err := c.unmarshalTable("processors."+name, table, processor); err != nil {
+		return nil, err
+	}
+	if err := c.initPlugin("processors."+name, processor); err != nil {
+		return nil, err
 	}
 
-	rf := &models.RunningProcessor{
-		Name:      name,
-		Processor: processor,
-		Config:    processorConfig,
+	// Each worker has its own instance of the processor.
+	instances := []telegraf.Processor{processor}
+	for len(instances) < processorConfig.Workers {
+		p := creator()
+		if err := toml.UnmarshalTable(table, p); err != nil {
+			return nil, err
+		}
+		if err := c.initPlugin("processors."+name, p); err != nil {
+			return nil, err
+		}
+		instances = append(instances, p)
 	}
 
-	c.Processors = append(c.Processors, rf)
-	return nil
+	return models.NewRunningProcessor(name, processorConfig, instances...), nil
 }
 
 func (c *Config) addOutput(name string, table *ast.Table) error {
 	if len(c.OutputFilters) > 0 && !sliceContains(name, c.OutputFilters) {
 		return nil
 	}
+	if c.checker == nil {
+		if err := c.resolveSecrets(table); err != nil {
+			return fmt.Errorf("Error resolving secrets of output %s: %s", name, err)
+		}
+	}
+	id := pluginID("outputs."+name, table)
+	if ro, ok := c.previousPlugin(id).(*models.RunningOutput); ok {
+		c.Outputs = append(c.Outputs, ro)
+		return nil
+	}
+
+	deadLetter, reasons, err := c.buildDeadLetter(name, table)
+	if err != nil {
+		return err
+	}
+
+	ro, err := c.newRunningOutput(name, table)
+	if err != nil {
+		return err
+	}
+	ro.ID = id
+	if deadLetter != nil {
+		ro.SetDeadLetter(deadLetter, reasons)
+	}
+	c.Outputs = append(c.Outputs, ro)
+	return nil
+}
+
+// newRunningOutput creates the output plugin with the given name, configured
+// from the ast.Table, and wraps it in a models.RunningOutput.
+func (c *Config) newRunningOutput(name string, table *ast.Table) (*models.RunningOutput, error) {
 	creator, ok := outputs.Outputs[name]
 	if !ok {
-		return fmt.Errorf("Undefined but requested output: %s", name)
+		return nil, fmt.Errorf("Undefined but requested output: %s", name)
 	}
 	output := creator()
+	if c.checker != nil {
+		c.checker.checkPlugin("outputs."+name, table, output)
+	}
 
 	// If the output has a SetSerializer function, then this means it can write
 	// arbitrary types of output, so build the serializer and set it.
@@ -809,24 +1046,134 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 	case serializers.SerializerOutput:
 		serializer, err := buildSerializer(name, table)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		t.SetSerializer(serializer)
 	}
 
+	outputProcessors, err := c.buildOutputProcessors(name, table)
+	if err != nil {
+		return nil, err
+	}
+
 	outputConfig, err := buildOutput(name, table)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := toml.UnmarshalTable(table, output); err != nil {
-		return err
+	if err := c.unmarshalTable("outputs."+name, table, output); err != nil {
+		return nil, err
+	}
+	if err := c.initPlugin("outputs."+name, output); err != nil {
+		return nil, err
 	}
 
 	ro := models.NewRunningOutput(name, output, outputConfig,
 		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
-	c.Outputs = append(c.Outputs, ro)
-	return nil
+	ro.Processors = outputProcessors
+	return ro, nil
+}
+
+// buildOutputProcessors parses the processors table of an output and builds
+// the processors applied to the metrics of this output only, sorted by
+// order. It returns no processors if no processors table is present.
+func (c *Config) buildOutputProcessors(name string, tbl *ast.Table) (models.RunningProcessors, error) {
+	node, ok := tbl.Fields["processors"]
+	if !ok {
+		return nil, nil
+	}
+	delete(tbl.Fields, "processors")
+
+	subtbl, ok := node.(*ast.Table)
+	if !ok {
+		return nil, fmt.Errorf("processors of output %s must be a table", name)
+	}
+
+	var rps models.RunningProcessors
+	for pluginName, pluginVal := range subtbl.Fields {
+		var pluginSubTables []*ast.Table
+		switch pluginSubTable := pluginVal.(type) {
+		case *ast.Table:
+			pluginSubTables = []*ast.Table{pluginSubTable}
+		case []*ast.Table:
+			pluginSubTables = pluginSubTable
+		default:
+			return nil, fmt.Errorf("Unsupported processors config format: %s, output %s",
+				pluginName, name)
+		}
+
+		for _, t := range pluginSubTables {
+			rp, err := c.newRunningProcessor(pluginName, name, t)
+			if err != nil {
+				return nil, err
+			}
+			if rp.Config.Workers > 1 {
+				return nil, fmt.Errorf("workers is not supported for the "+
+					"processors of output %s", name)
+			}
+			rps = append(rps, rp)
+		}
+	}
+
+	sort.Sort(rps)
+	return rps, nil
+}
+
+// buildDeadLetter parses the dead_letter table of an output and builds the
+// output receiving the dropped metrics along with the reasons to send them.
+// It returns a nil output if no dead_letter table is present.
+func (c *Config) buildDeadLetter(name string, tbl *ast.Table) (*models.RunningOutput, []string, error) {
+	node, ok := tbl.Fields["dead_letter"]
+	if !ok {
+		return nil, nil, nil
+	}
+	delete(tbl.Fields, "dead_letter")
+
+	subtbl, ok := node.(*ast.Table)
+	if !ok {
+		return nil, nil, fmt.Errorf("dead_letter of output %s must be a table", name)
+	}
+
+	reasons := models.DropReasons
+	if node, ok := subtbl.Fields["reasons"]; ok {
+		reasons = []string{}
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						if !sliceContains(str.Value, models.DropReasons) {
+							return nil, nil, fmt.Errorf("Invalid dead_letter reason %q for output %s",
+								str.Value, name)
+						}
+						reasons = append(reasons, str.Value)
+					}
+				}
+			}
+		}
+		delete(subtbl.Fields, "reasons")
+	}
+
+	if len(subtbl.Fields) != 1 {
+		return nil, nil, fmt.Errorf("dead_letter of output %s must contain exactly one output",
+			name)
+	}
+	for pluginName, pluginVal := range subtbl.Fields {
+		pluginSubTable, ok := pluginVal.(*ast.Table)
+		if !ok {
+			return nil, nil, fmt.Errorf("Unsupported dead_letter config format: %s, output %s",
+				pluginName, name)
+		}
+		if _, ok := pluginSubTable.Fields["dead_letter"]; ok {
+			return nil, nil, fmt.Errorf("dead_letter output of output %s can not have a dead_letter",
+				name)
+		}
+		ro, err := c.newRunningOutput(pluginName, pluginSubTable)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ro, reasons, nil
+	}
+	return nil, nil, nil
 }
 
 func (c *Config) addInput(name string, table *ast.Table) error {
@@ -837,12 +1184,25 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 	if name == "io" {
 		name = "diskio"
 	}
+	if c.checker == nil {
+		if err := c.resolveSecrets(table); err != nil {
+			return fmt.Errorf("Error resolving secrets of input %s: %s", name, err)
+		}
+	}
+	id := pluginID("inputs."+name, table)
+	if rp, ok := c.previousPlugin(id).(*models.RunningInput); ok {
+		c.Inputs = append(c.Inputs, rp)
+		return nil
+	}
 
 	creator, ok := inputs.Inputs[name]
 	if !ok {
 		return fmt.Errorf("Undefined but requested input: %s", name)
 	}
 	input := creator()
+	if c.checker != nil {
+		c.checker.checkPlugin("inputs."+name, table, input)
+	}
 
 	// If the input has a SetParser function, then this means it can accept
 	// arbitrary types of input, so build the parser and set it.
@@ -860,11 +1220,15 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, input); err != nil {
+	if err := c.unmarshalTable("inputs."+name, table, input); err != nil {
+		return err
+	}
+	if err := c.initPlugin("inputs."+name, input); err != nil {
 		return err
 	}
 
 	rp := models.NewRunningInput(input, pluginConfig)
+	rp.ID = id
 	c.Inputs = append(c.Inputs, rp)
 	return nil
 }
@@ -882,9 +1246,10 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 	}
 
 	conf := &models.AggregatorConfig{
-		Name:   name,
-		Delay:  time.Millisecond * 100,
-		Period: time.Second * 30,
+		Name:       name,
+		Delay:      time.Millisecond * 100,
+		Period:     time.Second * 30,
+		LatePolicy: models.LatePolicyDrop,
 	}
 
 	if node, ok := tbl.Fields["period"]; ok {
@@ -913,6 +1278,49 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 		}
 	}
 
+	if node, ok := tbl.Fields["late_policy"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				switch str.Value {
+				case models.LatePolicyDrop, models.LatePolicyCurrent,
+					models.LatePolicyCorrection:
+				default:
+					return nil, fmt.Errorf("Invalid late_policy %q for "+
+						"aggregator %s, must be %q, %q or %q", str.Value, name,
+						models.LatePolicyDrop, models.LatePolicyCurrent,
+						models.LatePolicyCorrection)
+				}
+
+				conf.LatePolicy = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["max_series"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				conf.MaxSeries = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["series_expiry"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				conf.SeriesExpiry = dur
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["drop_original"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if b, ok := kv.Value.(*ast.Boolean); ok {
@@ -960,11 +1368,15 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 
 	delete(tbl.Fields, "period")
 	delete(tbl.Fields, "delay")
+	delete(tbl.Fields, "late_policy")
+	delete(tbl.Fields, "max_series")
+	delete(tbl.Fields, "series_expiry")
 	delete(tbl.Fields, "drop_original")
 	delete(tbl.Fields, "name_prefix")
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "tags")
+	conf.Inputs = buildInputScope(tbl)
 	var err error
 	conf.Filter, err = buildFilter(tbl)
 	if err != nil {
@@ -977,7 +1389,11 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 // builds the filter and returns a
 // models.ProcessorConfig to be inserted into models.RunningProcessor
 func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error) {
-	conf := &models.ProcessorConfig{Name: name}
+	conf := &models.ProcessorConfig{
+		Name:     name,
+		Workers:  1,
+		Ordering: models.OrderingSeries,
+	}
 	unsupportedFields := []string{"tagexclude", "taginclude", "fielddrop", "fieldpass"}
 	for _, field := range unsupportedFields {
 		if _, ok := tbl.Fields[field]; ok {
@@ -998,7 +1414,42 @@ func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error
 		}
 	}
 
+	if node, ok := tbl.Fields["workers"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				if v < 1 {
+					return nil, fmt.Errorf("Invalid workers %d for processor "+
+						"%s, must be at least 1", v, name)
+				}
+				conf.Workers = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["ordering"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				switch str.Value {
+				case models.OrderingSeries, models.OrderingNone:
+				default:
+					return nil, fmt.Errorf("Invalid ordering %q for "+
+						"processor %s, must be %q or %q", str.Value, name,
+						models.OrderingSeries, models.OrderingNone)
+				}
+
+				conf.Ordering = str.Value
+			}
+		}
+	}
+
 	delete(tbl.Fields, "order")
+	delete(tbl.Fields, "workers")
+	delete(tbl.Fields, "ordering")
+	conf.Inputs = buildInputScope(tbl)
 	var err error
 	conf.Filter, err = buildFilter(tbl)
 	if err != nil {
@@ -1007,6 +1458,26 @@ func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error
 	return conf, nil
 }
 
+// buildInputScope parses the inputs whose metrics a processor or an
+// aggregator applies to.
+func buildInputScope(tbl *ast.Table) models.InputScope {
+	var scope models.InputScope
+	if node, ok := tbl.Fields["inputs"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						scope = append(scope, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	delete(tbl.Fields, "inputs")
+	return scope
+}
+
 // buildFilter builds a Filter
 // (tagpass/tagdrop/namepass/namedrop/fieldpass/fielddrop) to
 // be inserted into the models.OutputConfig/models.InputConfig
@@ -1162,35 +1633,108 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
-	if node, ok := tbl.Fields["name_prefix"]; ok {
+	if node, ok := tbl.Fields["round_interval"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				round, err := strconv.ParseBool(b.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.RoundInterval = &round
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["collection_jitter"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
-				cp.MeasurementPrefix = str.Value
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.CollectionJitter = dur
 			}
 		}
 	}
 
-	if node, ok := tbl.Fields["name_suffix"]; ok {
+	if node, ok := tbl.Fields["collection_offset"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
-				cp.MeasurementSuffix = str.Value
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.CollectionOffset = dur
 			}
 		}
 	}
 
-	if node, ok := tbl.Fields["name_override"]; ok {
+	if node, ok := tbl.Fields["gather_timeout"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
-				cp.NameOverride = str.Value
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.GatherTimeout = dur
 			}
 		}
 	}
 
-	cp.Tags = make(map[string]string)
-	if node, ok := tbl.Fields["tags"]; ok {
-		if subtbl, ok := node.(*ast.Table); ok {
-			if err := toml.UnmarshalTable(subtbl, cp.Tags); err != nil {
-				log.Printf("E! Could not parse tags for input %s\n", name)
+	if node, ok := tbl.Fields["schedule"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				if _, err := cron.Parse(str.Value); err != nil {
+					return nil, fmt.Errorf("Invalid schedule for input %s: %s",
+						name, err)
+				}
+
+				cp.Schedule = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["name_prefix"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.MeasurementPrefix = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["name_suffix"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.MeasurementSuffix = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["name_override"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.NameOverride = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["alias"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.Alias = str.Value
+			}
+		}
+	}
+
+	cp.Tags = make(map[string]string)
+	if node, ok := tbl.Fields["tags"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			if err := toml.UnmarshalTable(subtbl, cp.Tags); err != nil {
+				log.Printf("E! Could not parse tags for input %s\n", name)
 			}
 		}
 	}
@@ -1199,6 +1743,12 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "interval")
+	delete(tbl.Fields, "round_interval")
+	delete(tbl.Fields, "collection_jitter")
+	delete(tbl.Fields, "collection_offset")
+	delete(tbl.Fields, "gather_timeout")
+	delete(tbl.Fields, "schedule")
+	delete(tbl.Fields, "alias")
 	delete(tbl.Fields, "tags")
 	var err error
 	cp.Filter, err = buildFilter(tbl)
@@ -1261,6 +1811,62 @@ func buildParser(name string, tbl *ast.Table) (parsers.Parser, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["json_query"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONQuery = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_name_key"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONNameKey = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_string_fields"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.JSONStringFields = append(c.JSONStringFields, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_field_keys"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.JSONFieldKeys = append(c.JSONFieldKeys, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_time_key"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONTimeKey = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_time_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONTimeFormat = str.Value
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["data_type"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1338,12 +1944,170 @@ func buildParser(name string, tbl *ast.Table) (parsers.Parser, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["csv_header_row_count"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				c.CSVHeaderRowCount = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_column_names"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.CSVColumnNames = append(c.CSVColumnNames, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_skip_rows"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				c.CSVSkipRows = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_skip_columns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				c.CSVSkipColumns = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_delimiter"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVDelimiter = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_comment"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVComment = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_trim_space"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.CSVTrimSpace, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_tag_columns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.CSVTagColumns = append(c.CSVTagColumns, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_measurement_column"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVMeasurementColumn = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_timestamp_column"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVTimestampColumn = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["csv_timestamp_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.CSVTimestampFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_patterns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.GrokPatterns = append(c.GrokPatterns, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_custom_patterns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.GrokCustomPatterns = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_custom_pattern_files"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.GrokCustomPatternFiles = append(c.GrokCustomPatternFiles, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_timezone"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.GrokTimezone = str.Value
+			}
+		}
+	}
+
 	c.MetricName = name
 
 	delete(tbl.Fields, "data_format")
 	delete(tbl.Fields, "separator")
 	delete(tbl.Fields, "templates")
 	delete(tbl.Fields, "tag_keys")
+	delete(tbl.Fields, "json_query")
+	delete(tbl.Fields, "json_name_key")
+	delete(tbl.Fields, "json_string_fields")
+	delete(tbl.Fields, "json_field_keys")
+	delete(tbl.Fields, "json_time_key")
+	delete(tbl.Fields, "json_time_format")
 	delete(tbl.Fields, "data_type")
 	delete(tbl.Fields, "collectd_auth_file")
 	delete(tbl.Fields, "collectd_security_level")
@@ -1353,6 +2117,21 @@ func buildParser(name string, tbl *ast.Table) (parsers.Parser, error) {
 	delete(tbl.Fields, "dropwizard_time_format")
 	delete(tbl.Fields, "dropwizard_tags_path")
 	delete(tbl.Fields, "dropwizard_tag_paths")
+	delete(tbl.Fields, "csv_header_row_count")
+	delete(tbl.Fields, "csv_column_names")
+	delete(tbl.Fields, "csv_skip_rows")
+	delete(tbl.Fields, "csv_skip_columns")
+	delete(tbl.Fields, "csv_delimiter")
+	delete(tbl.Fields, "csv_comment")
+	delete(tbl.Fields, "csv_trim_space")
+	delete(tbl.Fields, "csv_tag_columns")
+	delete(tbl.Fields, "csv_measurement_column")
+	delete(tbl.Fields, "csv_timestamp_column")
+	delete(tbl.Fields, "csv_timestamp_format")
+	delete(tbl.Fields, "grok_patterns")
+	delete(tbl.Fields, "grok_custom_patterns")
+	delete(tbl.Fields, "grok_custom_pattern_files")
+	delete(tbl.Fields, "grok_timezone")
 
 	return parsers.NewParser(c)
 }
@@ -1361,7 +2140,10 @@ func buildParser(name string, tbl *ast.Table) (parsers.Parser, error) {
 // a serializers.Serializer object, and creates it, which can then be added onto
 // an Output object.
 func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error) {
-	c := &serializers.Config{TimestampUnits: time.Duration(1 * time.Second)}
+	c := &serializers.Config{
+		TimestampUnits:          time.Duration(1 * time.Second),
+		PrometheusStringAsLabel: true,
+	}
 
 	if node, ok := tbl.Fields["data_format"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
@@ -1455,6 +2237,30 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["prometheus_string_as_label"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.PrometheusStringAsLabel, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["prometheus_export_timestamp"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.PrometheusExportTimestamp, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
 	delete(tbl.Fields, "influx_max_line_bytes")
 	delete(tbl.Fields, "influx_sort_fields")
 	delete(tbl.Fields, "influx_uint_support")
@@ -1463,6 +2269,8 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 	delete(tbl.Fields, "prefix")
 	delete(tbl.Fields, "template")
 	delete(tbl.Fields, "json_timestamp_units")
+	delete(tbl.Fields, "prometheus_string_as_label")
+	delete(tbl.Fields, "prometheus_export_timestamp")
 	return serializers.NewSerializer(c)
 }
 
@@ -1479,6 +2287,85 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		Name:   name,
 		Filter: filter,
 	}
+
+	if node, ok := tbl.Fields["buffer_path"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.BufferPath = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["buffer_segment_size"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				oc.BufferSegmentSize = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["buffer_max_bytes"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				oc.BufferMaxBytes = v
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["flush_interval"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				oc.FlushInterval = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["flush_jitter"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				oc.FlushJitter = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["write_timeout"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				oc.WriteTimeout = dur
+			}
+		}
+	}
+
+	delete(tbl.Fields, "buffer_path")
+	delete(tbl.Fields, "buffer_segment_size")
+	delete(tbl.Fields, "buffer_max_bytes")
+	delete(tbl.Fields, "flush_interval")
+	delete(tbl.Fields, "flush_jitter")
+	delete(tbl.Fields, "write_timeout")
+
 	// Outputs don't support FieldDrop/FieldPass, so set to NameDrop/NamePass
 	if len(oc.Filter.FieldDrop) > 0 {
 		oc.Filter.NameDrop = oc.Filter.FieldDrop
@@ -6,13 +6,19 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf/internal/models"
+	_ "github.com/influxdata/telegraf/plugins/aggregators/merge"
+	_ "github.com/influxdata/telegraf/plugins/aggregators/minmax"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/inputs/exec"
 	"github.com/influxdata/telegraf/plugins/inputs/memcached"
 	"github.com/influxdata/telegraf/plugins/inputs/procstat"
+	"github.com/influxdata/telegraf/plugins/outputs/file"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	_ "github.com/influxdata/telegraf/plugins/processors/printer"
+	_ "github.com/influxdata/telegraf/plugins/processors/script"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConfig_LoadSingleInputWithEnvVars(t *testing.T) {
@@ -174,3 +180,156 @@ func TestConfig_LoadDirectory(t *testing.T) {
 	assert.Equal(t, pConfig, c.Inputs[3].Config,
 		"Merged Testdata did not produce correct procstat metadata.")
 }
+
+func TestConfig_LoadDeadLetter(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/dead_letter.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Outputs, 1)
+
+	dl := c.Outputs[0].DeadLetter()
+	require.NotNil(t, dl)
+	assert.Equal(t, "file", dl.Name)
+	assert.Equal(t, []string{"/tmp/dead_letter.out"}, dl.Output.(*file.File).Files)
+	assert.Equal(t, []string{"stdout"}, c.Outputs[0].Output.(*file.File).Files)
+}
+
+func TestConfig_LoadDeadLetterInvalidReason(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/dead_letter_invalid_reason.toml")
+	assert.Error(t, err)
+}
+
+func TestConfig_LoadSchedule(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/schedule.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Inputs, 2)
+
+	assert.Equal(t, "5 * * * *", c.Inputs[0].Config.Schedule)
+	assert.Nil(t, c.Inputs[0].Config.RoundInterval)
+
+	conf := c.Inputs[1].Config
+	assert.Equal(t, time.Minute, conf.Interval)
+	require.NotNil(t, conf.RoundInterval)
+	assert.False(t, *conf.RoundInterval)
+	assert.Equal(t, 5*time.Second, conf.CollectionJitter)
+	assert.Equal(t, 30*time.Second, conf.CollectionOffset)
+	assert.Empty(t, conf.Schedule)
+}
+
+func TestConfig_LoadScheduleInvalid(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/schedule_invalid.toml")
+	assert.Error(t, err)
+}
+
+func TestConfig_LoadLatePolicy(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/late_policy.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Aggregators, 2)
+	assert.Equal(t, models.LatePolicyDrop, c.Aggregators[0].Config.LatePolicy)
+	assert.Equal(t, models.LatePolicyCorrection, c.Aggregators[1].Config.LatePolicy)
+}
+
+func TestConfig_LoadLatePolicyInvalid(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/late_policy_invalid.toml")
+	assert.Error(t, err)
+}
+
+func TestConfig_LoadSeriesLimits(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/series_limits.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Aggregators, 1)
+	assert.Equal(t, 1000, c.Aggregators[0].Config.MaxSeries)
+	assert.Equal(t, time.Hour, c.Aggregators[0].Config.SeriesExpiry)
+}
+
+func TestConfig_LoadSeriesLimitsUnsupported(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/series_limits_unsupported.toml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported by aggregator merge")
+}
+
+func TestConfig_LoadProcessorWorkers(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/processor_workers.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Processors, 2)
+
+	conf := c.Processors[0].Config
+	assert.Equal(t, 1, conf.Workers)
+	assert.Equal(t, models.OrderingSeries, conf.Ordering)
+
+	conf = c.Processors[1].Config
+	assert.Equal(t, 3, conf.Workers)
+	assert.Equal(t, models.OrderingNone, conf.Ordering)
+}
+
+func TestConfig_LoadProcessorWorkersInvalid(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/processor_workers_invalid.toml")
+	assert.Error(t, err)
+}
+
+func TestConfig_LoadScopedPlugins(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/scoped_plugins.toml")
+	require.NoError(t, err)
+
+	require.Len(t, c.Inputs, 1)
+	assert.Equal(t, "cache", c.Inputs[0].Config.Alias)
+	require.Len(t, c.Processors, 1)
+	assert.Equal(t, models.InputScope{"cache"}, c.Processors[0].Config.Inputs)
+	require.Len(t, c.Aggregators, 1)
+	assert.Equal(t, models.InputScope{"cache", "exec"},
+		c.Aggregators[0].Config.Inputs)
+
+	require.Len(t, c.Outputs, 1)
+	processors := c.Outputs[0].Processors
+	require.Len(t, processors, 2)
+	assert.Equal(t, int64(1), processors[0].Config.Order)
+	assert.Equal(t, []string{"cpu"}, processors[0].Config.Filter.NamePass)
+	assert.Equal(t, int64(2), processors[1].Config.Order)
+	assert.Equal(t, c.Outputs[0].Config.Name, processors[0].Config.Output)
+	assert.Equal(t, c.Outputs[0].Config.Name, processors[0].ProcessTime.Tags()["output"])
+	assert.Equal(t, "", c.Processors[0].Config.Output)
+}
+
+func TestConfig_LoadOutputProcessorsInvalid(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/output_processors_invalid.toml")
+	assert.Error(t, err)
+}
+
+func TestConfig_ReusePlugins(t *testing.T) {
+	prev := NewConfig()
+	require.NoError(t, prev.LoadConfig("./testdata/single_plugin.toml"))
+	require.NoError(t, prev.LoadConfig("./testdata/dead_letter.toml"))
+
+	c := NewConfig()
+	c.ReusePlugins(prev)
+	require.NoError(t, c.LoadConfig("./testdata/single_plugin.toml"))
+	require.NoError(t, c.LoadConfig("./testdata/subconfig/memcached.conf"))
+	require.NoError(t, c.LoadConfig("./testdata/dead_letter.toml"))
+
+	require.Len(t, c.Inputs, 2)
+	assert.True(t, prev.Inputs[0] == c.Inputs[0],
+		"unchanged input was not reused")
+	assert.True(t, prev.Inputs[0] != c.Inputs[1],
+		"changed input was reused")
+	require.Len(t, c.Outputs, 1)
+	assert.True(t, prev.Outputs[0] == c.Outputs[0],
+		"unchanged output was not reused")
+}
+
+func TestConfig_LoadInitError(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/init_invalid.toml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Error initializing processors.script")
+}
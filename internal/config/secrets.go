@@ -0,0 +1,226 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/toml/ast"
+	"github.com/kballard/go-shellquote"
+)
+
+// secretRefRe matches references to secrets in the string values of the
+// config, of the form @{provider:key}, and the escaped @@{ standing for a
+// literal @{.
+var secretRefRe = regexp.MustCompile(`@@\{|@\{(\w+):([^}]*)\}`)
+
+// secretExecTimeout is the time the exec provider waits for a helper to
+// print a secret.
+var secretExecTimeout = 10 * time.Second
+
+// SecretRedacted replaces the secrets in the output of Config.RedactSecrets.
+const SecretRedacted = "<redacted>"
+
+// SecretProvider resolves references to secrets in the config.
+type SecretProvider interface {
+	// Resolve returns the secret identified by key.
+	Resolve(key string) (string, error)
+}
+
+// SecretProviderFunc is an adapter to use a function as a SecretProvider.
+type SecretProviderFunc func(key string) (string, error)
+
+// Resolve calls f(key).
+func (f SecretProviderFunc) Resolve(key string) (string, error) {
+	return f(key)
+}
+
+var (
+	secretProvidersMu sync.RWMutex
+	secretProviders   = map[string]SecretProvider{
+		"env":  SecretProviderFunc(resolveEnvSecret),
+		"file": SecretProviderFunc(resolveFileSecret),
+		"exec": SecretProviderFunc(resolveExecSecret),
+	}
+)
+
+// AddSecretProvider registers a provider resolving the references of the
+// form @{name:key}, replacing any provider of the same name.
+func AddSecretProvider(name string, provider SecretProvider) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+	secretProviders[name] = provider
+}
+
+// lookupSecretProvider returns the provider registered with the given name.
+func lookupSecretProvider(name string) (SecretProvider, bool) {
+	secretProvidersMu.RLock()
+	defer secretProvidersMu.RUnlock()
+	provider, ok := secretProviders[name]
+	return provider, ok
+}
+
+// RedactSecrets returns the value of a setting with the secrets resolved
+// while loading the config replaced by a placeholder. Only whole values
+// holding secret references are redacted, other values are returned as is.
+func (c *Config) RedactSecrets(s string) string {
+	if redacted, ok := c.secrets[s]; ok {
+		return redacted
+	}
+	return s
+}
+
+// resolveEnvSecret returns the value of the environment variable key.
+func resolveEnvSecret(key string) (string, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", key)
+	}
+	return value, nil
+}
+
+// resolveFileSecret returns the contents of the file at path key, without
+// trailing newlines.
+func resolveFileSecret(key string) (string, error) {
+	contents, err := ioutil.ReadFile(key)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(contents), "\r\n"), nil
+}
+
+// resolveExecSecret runs the command key and returns what it prints to
+// stdout, without trailing newlines.
+func resolveExecSecret(key string) (string, error) {
+	args, err := shellquote.Split(key)
+	if err != nil || len(args) == 0 {
+		return "", fmt.Errorf("unable to parse command %q: %v", key, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := internal.RunTimeout(cmd, secretExecTimeout); err != nil {
+		return "", fmt.Errorf("command %q failed: %s: %s", args[0], err,
+			strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// resolveSecretRefs replaces the secret references in s with the secrets
+// they refer to, and @@{ with @{. It also returns s with the references
+// replaced by a placeholder, and whether s had any. References to providers
+// that are not registered are left as is.
+func resolveSecretRefs(s string) (string, string, bool, error) {
+	if !strings.Contains(s, "@{") {
+		return s, s, false, nil
+	}
+
+	var err error
+	var found bool
+	var redacted bytes.Buffer
+	var resolved bytes.Buffer
+	last := 0
+	for _, loc := range secretRefRe.FindAllStringSubmatchIndex(s, -1) {
+		redacted.WriteString(s[last:loc[0]])
+		resolved.WriteString(s[last:loc[0]])
+		last = loc[1]
+
+		ref := s[loc[0]:loc[1]]
+		if loc[2] < 0 {
+			// escaped @{
+			redacted.WriteString("@{")
+			resolved.WriteString("@{")
+			continue
+		}
+		provider, ok := lookupSecretProvider(s[loc[2]:loc[3]])
+		if !ok {
+			redacted.WriteString(ref)
+			resolved.WriteString(ref)
+			continue
+		}
+
+		secret, rerr := provider.Resolve(s[loc[4]:loc[5]])
+		if rerr != nil {
+			err = fmt.Errorf("unable to resolve %s: %s", ref, rerr)
+			break
+		}
+		found = true
+		redacted.WriteString(SecretRedacted)
+		resolved.WriteString(secret)
+	}
+	if err != nil {
+		return s, s, false, err
+	}
+	redacted.WriteString(s[last:])
+	resolved.WriteString(s[last:])
+	return resolved.String(), redacted.String(), found, nil
+}
+
+// resolveSecrets replaces the secret references in the string values of tbl
+// and of its subtables, recording the values holding secrets for
+// RedactSecrets.
+func (c *Config) resolveSecrets(tbl *ast.Table) error {
+	return walkStrings(tbl, func(_ *ast.KeyValue, str *ast.String) error {
+		resolved, redacted, found, err := resolveSecretRefs(str.Value)
+		if err != nil {
+			return err
+		}
+		if found && resolved != "" {
+			if c.secrets == nil {
+				c.secrets = make(map[string]string)
+			}
+			c.secrets[resolved] = redacted
+		}
+		str.Value = resolved
+		return nil
+	})
+}
+
+// walkStrings calls fn for the string values of tbl and of its subtables,
+// including the strings in arrays, along with the key they belong to. It
+// stops at the first error returned by fn.
+func walkStrings(tbl *ast.Table, fn func(*ast.KeyValue, *ast.String) error) error {
+	for _, field := range tbl.Fields {
+		if err := walkStringsValue(nil, field, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkStringsValue(
+	kv *ast.KeyValue,
+	v interface{},
+	fn func(*ast.KeyValue, *ast.String) error,
+) error {
+	switch v := v.(type) {
+	case *ast.KeyValue:
+		return walkStringsValue(v, v.Value, fn)
+	case *ast.String:
+		return fn(kv, v)
+	case *ast.Array:
+		for _, elem := range v.Value {
+			if err := walkStringsValue(kv, elem, fn); err != nil {
+				return err
+			}
+		}
+	case *ast.Table:
+		return walkStrings(v, fn)
+	case []*ast.Table:
+		for _, t := range v {
+			if err := walkStrings(t, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
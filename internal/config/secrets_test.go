@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/influxdata/telegraf/plugins/inputs/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_LoadSecrets(t *testing.T) {
+	require.NoError(t, os.Setenv("TEST_SECRET_PATH", "metrics"))
+	require.NoError(t, os.Setenv("TEST_SECRET_USER", "telegraf"))
+
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/secrets.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Inputs, 1)
+
+	input := c.Inputs[0].Input.(*http.HTTP)
+	assert.Equal(t, []string{"http://localhost/metrics"}, input.URLs)
+	assert.Equal(t, "telegraf", input.Username)
+	assert.Equal(t, "s3cr3t", input.Password)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer token"},
+		input.Headers)
+
+	assert.Equal(t, "<redacted>", c.RedactSecrets("s3cr3t"))
+	assert.Equal(t, "Bearer <redacted>", c.RedactSecrets("Bearer token"))
+	assert.Equal(t, "http://localhost/<redacted>",
+		c.RedactSecrets("http://localhost/metrics"))
+	// Only whole values are redacted.
+	assert.Equal(t, "password=s3cr3t", c.RedactSecrets("password=s3cr3t"))
+
+	// The secrets of a previously loaded config are not redacted.
+	assert.Equal(t, "s3cr3t", NewConfig().RedactSecrets("s3cr3t"))
+}
+
+func TestConfig_LoadSecretsUnknownProvider(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/secrets_unknown_provider.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Inputs, 1)
+
+	input := c.Inputs[0].Input.(*http.HTTP)
+	assert.Equal(t, "@{vault:db_pw}", input.Password)
+	assert.Equal(t, "@{env:TEST_SECRET_USER}", input.Username)
+}
+
+func TestResolveSecretRefs(t *testing.T) {
+	AddSecretProvider("test", SecretProviderFunc(func(key string) (string, error) {
+		if key == "missing" {
+			return "", errors.New("not found")
+		}
+		return "value-of-" + key, nil
+	}))
+
+	s, redacted, found, err := resolveSecretRefs("@{test:a}:@{test:b}")
+	require.NoError(t, err)
+	assert.Equal(t, "value-of-a:value-of-b", s)
+	assert.Equal(t, "<redacted>:<redacted>", redacted)
+	assert.True(t, found)
+
+	s, _, found, err = resolveSecretRefs("no references, not even @{this}")
+	require.NoError(t, err)
+	assert.Equal(t, "no references, not even @{this}", s)
+	assert.False(t, found)
+
+	s, _, found, err = resolveSecretRefs("@{unknown:a} @@{test:a} @{test:a}")
+	require.NoError(t, err)
+	assert.Equal(t, "@{unknown:a} @{test:a} value-of-a", s)
+	assert.True(t, found)
+
+	_, _, _, err = resolveSecretRefs("@{test:missing}")
+	assert.Error(t, err)
+
+	_, _, _, err = resolveSecretRefs("@{env:TEST_SECRET_UNSET}")
+	assert.Error(t, err)
+
+	_, _, _, err = resolveSecretRefs("@{file:./testdata/does_not_exist}")
+	assert.Error(t, err)
+
+	_, _, _, err = resolveSecretRefs("@{exec:false}")
+	assert.Error(t, err)
+}
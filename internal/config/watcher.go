@@ -0,0 +1,153 @@
+package config
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDelay is how long the Watcher waits for further events before
+// notifying about a change, as editors often save a file in several steps.
+var watchDelay = time.Second
+
+// Watcher notifies about changes to the config file and the config directory.
+type Watcher struct {
+	path string
+	dir  string
+
+	watcher *fsnotify.Watcher
+	changes chan struct{}
+	done    chan struct{}
+}
+
+// NewWatcher returns a Watcher for the config file at path, or the default
+// config file if path is empty, and for the *.conf files in dir and its
+// subdirectories if dir is not empty.
+func NewWatcher(path, dir string) (*Watcher, error) {
+	var err error
+	if path == "" {
+		if path, err = getDefaultConfigPath(); err != nil {
+			return nil, err
+		}
+	}
+
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		path:    filepath.Clean(path),
+		watcher: fw,
+		changes: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	// Watch the directory of the config file rather than the file itself, so
+	// that changes are still seen when an editor replaces the file.
+	if err := fw.Add(filepath.Dir(w.path)); err != nil {
+		fw.Close()
+		return nil, err
+	}
+	if dir != "" {
+		w.dir = filepath.Clean(dir)
+		if err := w.addDirectory(w.dir); err != nil {
+			fw.Close()
+			return nil, err
+		}
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// Changes returns a channel receiving a value after the config changed.
+func (w *Watcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Close stops watching the config.
+func (w *Watcher) Close() error {
+	err := w.watcher.Close()
+	<-w.done
+	return err
+}
+
+// addDirectory watches dir and its subdirectories, skipping the same
+// directories as LoadDirectory.
+func (w *Watcher) addDirectory(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, _ error) error {
+		if info == nil || !info.IsDir() {
+			return nil
+		}
+		if path != dir && strings.HasPrefix(info.Name(), "..") {
+			return filepath.SkipDir
+		}
+		return w.watcher.Add(path)
+	})
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+
+	var timer <-chan time.Time
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod || !w.isConfig(event.Name) {
+				continue
+			}
+			if event.Op&fsnotify.Create != 0 && w.inDirectory(event.Name) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := w.addDirectory(event.Name); err != nil {
+						log.Printf("E! Unable to watch %s: %s", event.Name, err)
+					}
+				}
+			}
+			timer = time.After(watchDelay)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("E! Error watching config: %s", err)
+		case <-timer:
+			timer = nil
+			select {
+			case w.changes <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// isConfig returns true if a change to the file at path can change the
+// config.
+func (w *Watcher) isConfig(path string) bool {
+	path = filepath.Clean(path)
+	if path == w.path {
+		return true
+	}
+	// Kubernetes updates mounted config maps by swapping a ..data symlink.
+	if strings.HasPrefix(filepath.Base(path), "..") {
+		return true
+	}
+	if w.inDirectory(path) {
+		if strings.HasSuffix(path, ".conf") {
+			return true
+		}
+		info, err := os.Stat(path)
+		return err != nil || info.IsDir()
+	}
+	return false
+}
+
+// inDirectory returns true if path is below the config directory.
+func (w *Watcher) inDirectory(path string) bool {
+	return w.dir != "" && strings.HasPrefix(path, w.dir+string(filepath.Separator))
+}
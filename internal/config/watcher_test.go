@@ -0,0 +1,48 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitChange(w *Watcher) bool {
+	select {
+	case <-w.Changes():
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	watchDelay = 10 * time.Millisecond
+	dir, err := ioutil.TempDir("", "telegraf-watcher")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "telegraf.conf")
+	confDir := filepath.Join(dir, "telegraf.d")
+	require.NoError(t, ioutil.WriteFile(path, []byte(""), 0640))
+	require.NoError(t, os.Mkdir(confDir, 0750))
+
+	w, err := NewWatcher(path, confDir)
+	require.NoError(t, err)
+	defer w.Close()
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("[agent]\n"), 0640))
+	assert.True(t, waitChange(w), "config file change")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "other.txt"), []byte(""), 0640))
+	assert.False(t, waitChange(w), "unrelated file change")
+
+	require.NoError(t, os.Mkdir(filepath.Join(confDir, "sub"), 0750))
+	assert.True(t, waitChange(w), "new subdirectory")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(confDir, "sub", "cpu.conf"), []byte(""), 0640))
+	assert.True(t, waitChange(w), "config directory change")
+}
@@ -0,0 +1,73 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04:05", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		spec     string
+		from     string
+		expected string
+	}{
+		{"* * * * *", "2018-07-10 10:04:30", "2018-07-10 10:05:00"},
+		{"5 * * * *", "2018-07-10 10:04:30", "2018-07-10 10:05:00"},
+		{"5 * * * *", "2018-07-10 10:05:00", "2018-07-10 11:05:00"},
+		{"*/15 * * * *", "2018-07-10 10:31:00", "2018-07-10 10:45:00"},
+		{"5/20 * * * *", "2018-07-10 10:46:00", "2018-07-10 11:05:00"},
+		{"0 9-17/4 * * *", "2018-07-10 13:00:00", "2018-07-10 17:00:00"},
+		{"30 2 * * mon-fri", "2018-07-13 03:00:00", "2018-07-16 02:30:00"},
+		{"0 0 * * 7", "2018-07-10 00:00:00", "2018-07-15 00:00:00"},
+		{"0 0 1,15 * *", "2018-07-02 00:00:00", "2018-07-15 00:00:00"},
+		{"0 0 31 * *", "2018-09-01 00:00:00", "2018-10-31 00:00:00"},
+		{"0 0 29 feb *", "2018-07-10 00:00:00", "2020-02-29 00:00:00"},
+		{"0 0 13 * fri", "2018-07-10 00:00:00", "2018-07-13 00:00:00"},
+		{"0 0 13 * fri", "2018-07-14 00:00:00", "2018-07-20 00:00:00"},
+		{"@hourly", "2018-07-10 10:04:30", "2018-07-10 11:00:00"},
+		{"@monthly", "2018-12-10 10:04:30", "2019-01-01 00:00:00"},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		require.NoError(t, err, tt.spec)
+		assert.Equal(t, date(tt.expected), s.Next(date(tt.from)),
+			"%s from %s", tt.spec, tt.from)
+	}
+}
+
+func TestNextNever(t *testing.T) {
+	s, err := Parse("0 0 30 feb *")
+	require.NoError(t, err)
+	assert.True(t, s.Next(date("2018-07-10 00:00:00")).IsZero())
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"x * * * *",
+		"@often",
+	} {
+		_, err := Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
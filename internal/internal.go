@@ -5,7 +5,9 @@ import (
 	"bytes"
 	"crypto/rand"
 	"errors"
+	"fmt"
 	"log"
+	"math"
 	"math/big"
 	"os"
 	"os/exec"
@@ -193,3 +195,50 @@ func RandomSleep(max time.Duration, shutdown chan struct{}) {
 		return
 	}
 }
+
+// ParseTimestamp parses a timestamp with the format: a Go time layout for
+// strings, or "unix", "unix_ms", "unix_us" or "unix_ns" for the seconds,
+// milliseconds, microseconds or nanoseconds since the Unix epoch, as numbers
+// or strings.
+func ParseTimestamp(value interface{}, format string) (time.Time, error) {
+	var unit time.Duration
+	switch format {
+	case "":
+		return time.Time{}, errors.New("time format must be set")
+	case "unix":
+		unit = time.Second
+	case "unix_ms":
+		unit = time.Millisecond
+	case "unix_us":
+		unit = time.Microsecond
+	case "unix_ns":
+		unit = time.Nanosecond
+	default:
+		str, ok := value.(string)
+		if !ok {
+			return time.Time{}, fmt.Errorf("time %v is not a string", value)
+		}
+		return time.Parse(format, str)
+	}
+
+	var ts float64
+	switch value := value.(type) {
+	case int64:
+		return time.Unix(0, value*int64(unit)).UTC(), nil
+	case float64:
+		ts = value
+	case string:
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Unix(0, i*int64(unit)).UTC(), nil
+		}
+		var err error
+		ts, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("time %q is not a number", value)
+		}
+	default:
+		return time.Time{}, fmt.Errorf("time %v is not a number", value)
+	}
+	sec, frac := math.Modf(ts * float64(unit) / float64(time.Second))
+	return time.Unix(int64(sec), int64(frac*float64(time.Second))).UTC(), nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type SnakeTest struct {
@@ -162,3 +163,29 @@ func TestDuration(t *testing.T) {
 	d.UnmarshalTOML([]byte(`1.5`))
 	assert.Equal(t, time.Second, d.Duration)
 }
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		value  interface{}
+		format string
+		time   time.Time
+	}{
+		{"2018-07-10T08:00:00Z", time.RFC3339, time.Unix(1531209600, 0)},
+		{1531209600.5, "unix", time.Unix(1531209600, 5e8)},
+		{"1531209600", "unix", time.Unix(1531209600, 0)},
+		{int64(1531209600123), "unix_ms", time.Unix(1531209600, 123e6)},
+		{"1531209600123456789", "unix_ns", time.Unix(1531209600, 123456789)},
+	}
+	for _, tt := range tests {
+		ts, err := ParseTimestamp(tt.value, tt.format)
+		require.NoError(t, err)
+		assert.True(t, tt.time.Equal(ts), "%v", tt.value)
+	}
+
+	_, err := ParseTimestamp("1531209600", "")
+	assert.Error(t, err)
+	_, err = ParseTimestamp(1531209600.0, time.RFC3339)
+	assert.Error(t, err)
+	_, err = ParseTimestamp("yesterday", "unix")
+	assert.Error(t, err)
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/filter"
 )
 
@@ -261,3 +262,21 @@ func (f *Filter) filterTags(tags map[string]string) {
 		}
 	}
 }
+
+// InputScope lists the inputs, by alias or name, whose metrics a processor or
+// an aggregator applies to. An empty scope applies to all metrics.
+type InputScope []string
+
+// Contains returns true if the metric was produced by one of the inputs of
+// the scope, or if the scope is empty.
+func (s InputScope) Contains(m telegraf.Metric) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, input := range s {
+		if m.Origin() == input {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"testing"
 
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -442,3 +443,13 @@ func TestFilter_FilterTagsPassAndDrop(t *testing.T) {
 	}
 
 }
+
+func TestInputScope_Contains(t *testing.T) {
+	m := testutil.TestMetric(1)
+	assert.False(t, InputScope{"frontend"}.Contains(m))
+	assert.True(t, InputScope{}.Contains(m))
+
+	m.SetOrigin("frontend")
+	assert.True(t, InputScope{"backend", "frontend"}.Contains(m))
+	assert.False(t, InputScope{"backend"}.Contains(m))
+}
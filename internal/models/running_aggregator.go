@@ -1,31 +1,106 @@
 package models
 
 import (
+	"container/list"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// Policies for the metrics received after the end of their period.
+const (
+	// LatePolicyDrop drops the late metrics.
+	LatePolicyDrop = "drop"
+	// LatePolicyCurrent adds the late metrics to the current period.
+	LatePolicyCurrent = "current"
+	// LatePolicyCorrection pushes the aggregates of the previous period again
+	// with its late metrics, the metrics of earlier periods being dropped.
+	LatePolicyCorrection = "correction"
 )
 
 type RunningAggregator struct {
 	a      telegraf.Aggregator
 	Config *AggregatorConfig
 
+	// ID identifies the configuration the plugin was loaded from. Plugins
+	// loaded from identical configuration have the same ID.
+	ID string
+
 	metrics chan telegraf.Metric
 
 	periodStart time.Time
 	periodEnd   time.Time
+
+	// next holds the metrics of the next period received before the current
+	// one is pushed.
+	next []telegraf.Metric
+	// With the correction policy, current and previous hold the metrics of
+	// the current and previous periods, and corrected is true if late
+	// metrics were added to the previous period.
+	current   []telegraf.Metric
+	previous  []telegraf.Metric
+	corrected bool
+
+	// MetricsLate counts the metrics received after the end of their
+	// period, and MetricsDropped those dropped for being late or too far in
+	// the future.
+	MetricsLate    selfstat.Stat
+	MetricsDropped selfstat.Stat
+
+	// expirer is the aggregator if it implements telegraf.SeriesExpirer and
+	// its series are limited or expire. series then holds the series it
+	// tracks, the least recently updated first, and index their elements by
+	// HashID.
+	expirer telegraf.SeriesExpirer
+	series  *list.List
+	index   map[uint64]*list.Element
+
+	// SeriesTracked is the number of series tracked, SeriesEvicted counts
+	// the series removed to stay within the maximum number of series, and
+	// SeriesExpired those removed for being idle.
+	SeriesTracked selfstat.Stat
+	SeriesEvicted selfstat.Stat
+	SeriesExpired selfstat.Stat
+
+	// pusher is the aggregator if it implements telegraf.DuePusher, and due
+	// the earliest time of the metrics it holds, zero if it holds none.
+	pusher telegraf.DuePusher
+	due    time.Time
+}
+
+// trackedSeries is a series tracked by the RunningAggregator.
+type trackedSeries struct {
+	id      uint64
+	updated time.Time
 }
 
 func NewRunningAggregator(
 	a telegraf.Aggregator,
 	conf *AggregatorConfig,
 ) *RunningAggregator {
-	return &RunningAggregator{
-		a:       a,
-		Config:  conf,
-		metrics: make(chan telegraf.Metric, 100),
+	tags := map[string]string{"aggregator": conf.Name}
+	r := &RunningAggregator{
+		a:              a,
+		Config:         conf,
+		metrics:        make(chan telegraf.Metric, 100),
+		MetricsLate:    selfstat.Register("aggregate", "metrics_late", tags),
+		MetricsDropped: selfstat.Register("aggregate", "metrics_dropped", tags),
+		SeriesTracked:  selfstat.Register("aggregate", "series_tracked", tags),
+		SeriesEvicted:  selfstat.Register("aggregate", "series_evicted", tags),
+		SeriesExpired:  selfstat.Register("aggregate", "series_expired", tags),
+	}
+
+	if e, ok := a.(telegraf.SeriesExpirer); ok && conf.LimitsSeries() {
+		r.expirer = e
+		r.series = list.New()
+		r.index = make(map[uint64]*list.Element)
 	}
+	if p, ok := a.(telegraf.DuePusher); ok {
+		r.pusher = p
+	}
+	return r
 }
 
 // AggregatorConfig containing configuration parameters for the running
@@ -39,9 +114,31 @@ type AggregatorConfig struct {
 	MeasurementSuffix string
 	Tags              map[string]string
 	Filter            Filter
+	Inputs            InputScope
 
 	Period time.Duration
 	Delay  time.Duration
+	// LatePolicy is the policy for the metrics received after the end of
+	// their period, LatePolicyDrop if empty.
+	LatePolicy string
+
+	// MaxSeries is the maximum number of series tracked by the aggregator,
+	// the least recently updated being evicted, and SeriesExpiry the time
+	// after which a series not updated is removed. Zero means no limit.
+	// They require the aggregator to implement telegraf.SeriesExpirer.
+	MaxSeries    int
+	SeriesExpiry time.Duration
+}
+
+// LimitsSeries returns true if the series of the aggregator are limited or
+// expire.
+func (c *AggregatorConfig) LimitsSeries() bool {
+	return c.MaxSeries > 0 || c.SeriesExpiry > 0
+}
+
+// Aggregator returns the aggregator plugin.
+func (r *RunningAggregator) Aggregator() telegraf.Aggregator {
+	return r.a
 }
 
 func (r *RunningAggregator) Name() string {
@@ -81,6 +178,9 @@ func (r *RunningAggregator) MakeMetric(
 // Before applying to the plugin, it will run any defined filters on the metric.
 // Apply returns true if the original metric should be dropped.
 func (r *RunningAggregator) Add(in telegraf.Metric) bool {
+	if !r.Config.Inputs.Contains(in) {
+		return false
+	}
 	if r.Config.Filter.IsActive() {
 		// check if the aggregator should apply this metric
 		name := in.Name()
@@ -98,47 +198,172 @@ func (r *RunningAggregator) Add(in telegraf.Metric) bool {
 	r.metrics <- in
 	return r.Config.DropOriginal
 }
+
+// add adds the metric to the period it belongs to, according to its time.
 func (r *RunningAggregator) add(in telegraf.Metric) {
+	t := in.Time()
+	switch {
+	case t.Before(r.periodStart):
+		r.MetricsLate.Incr(1)
+		switch r.Config.LatePolicy {
+		case LatePolicyCurrent:
+			r.aggregate(in)
+		case LatePolicyCorrection:
+			if r.previous != nil && !t.Before(r.periodStart.Add(-r.Config.Period)) {
+				r.previous = append(r.previous, in)
+				r.corrected = true
+				return
+			}
+			r.MetricsDropped.Incr(1)
+		default:
+			r.MetricsDropped.Incr(1)
+		}
+	case t.Before(r.periodEnd):
+		r.aggregate(in)
+		if r.Config.LatePolicy == LatePolicyCorrection {
+			r.current = append(r.current, in)
+		}
+	case t.Before(r.periodEnd.Add(r.Config.Period)):
+		// The metric belongs to the next period, it is added once the
+		// current one is pushed.
+		r.next = append(r.next, in)
+	default:
+		r.MetricsDropped.Incr(1)
+	}
+}
+
+// aggregate adds the metric to the aggregator, tracking its series.
+func (r *RunningAggregator) aggregate(in telegraf.Metric) {
+	if r.expirer != nil {
+		r.track(in.HashID(), time.Now())
+	}
 	r.a.Add(in)
+	if r.pusher != nil && (r.due.IsZero() || in.Time().Before(r.due)) {
+		r.due = in.Time()
+	}
 }
 
-func (r *RunningAggregator) push(acc telegraf.Accumulator) {
-	r.a.Push(acc)
+// track marks the series as updated at the given time, evicting the least
+// recently updated series if a new one exceeds the maximum number of series.
+func (r *RunningAggregator) track(id uint64, now time.Time) {
+	if e, ok := r.index[id]; ok {
+		e.Value.(*trackedSeries).updated = now
+		r.series.MoveToBack(e)
+		return
+	}
+
+	if r.Config.MaxSeries > 0 && r.series.Len() >= r.Config.MaxSeries {
+		r.remove(r.series.Front())
+		r.SeriesEvicted.Incr(1)
+	}
+	r.index[id] = r.series.PushBack(&trackedSeries{id: id, updated: now})
+	r.SeriesTracked.Set(int64(r.series.Len()))
+}
+
+// expire removes the series not updated for the series expiry.
+func (r *RunningAggregator) expire(now time.Time) {
+	for e := r.series.Front(); e != nil; e = r.series.Front() {
+		if now.Sub(e.Value.(*trackedSeries).updated) < r.Config.SeriesExpiry {
+			break
+		}
+		r.remove(e)
+		r.SeriesExpired.Incr(1)
+	}
 }
 
-func (r *RunningAggregator) reset() {
+// remove stops tracking the series and removes it from the aggregator.
+func (r *RunningAggregator) remove(e *list.Element) {
+	s := r.series.Remove(e).(*trackedSeries)
+	delete(r.index, s.id)
+	r.expirer.Expire(s.id)
+	r.SeriesTracked.Set(int64(r.series.Len()))
+}
+
+// push pushes the aggregates of the current period, stamped with its end,
+// and those of the previous period if it was corrected, then starts the next
+// period.
+func (r *RunningAggregator) push(acc telegraf.Accumulator) {
+	r.a.Push(&periodAccumulator{Accumulator: acc, end: r.periodEnd})
 	r.a.Reset()
+	r.due = time.Time{}
+
+	if r.corrected {
+		for _, m := range r.previous {
+			r.a.Add(m)
+		}
+		r.a.Push(&periodAccumulator{Accumulator: acc, end: r.periodStart})
+		r.a.Reset()
+		r.corrected = false
+	}
+	if r.Config.LatePolicy == LatePolicyCorrection {
+		r.previous = r.current
+		if r.previous == nil {
+			r.previous = []telegraf.Metric{}
+		}
+		r.current = nil
+	}
+
+	if r.expirer != nil && r.Config.SeriesExpiry > 0 {
+		r.expire(time.Now())
+	}
+
+	r.periodStart = r.periodEnd
+	r.periodEnd = r.periodStart.Add(r.Config.Period)
+	next := r.next
+	r.next = nil
+	for _, m := range next {
+		r.add(m)
+	}
+}
+
+// pushDue pushes the aggregates of the metrics whose time is at least the
+// delay before now.
+func (r *RunningAggregator) pushDue(acc telegraf.Accumulator, now time.Time) {
+	r.due = r.pusher.PushUntil(
+		&periodAccumulator{Accumulator: acc, end: r.periodEnd},
+		now.Add(-r.Config.Delay),
+	)
+}
+
+// nextPush returns the time of the next push: the end of the current period
+// after the delay, or earlier if the aggregator pushes its aggregates once
+// they are due.
+func (r *RunningAggregator) nextPush() time.Time {
+	next := r.periodEnd.Add(r.Config.Delay)
+	if !r.due.IsZero() && r.due.Add(r.Config.Delay).Before(next) {
+		return r.due.Add(r.Config.Delay)
+	}
+	return next
 }
 
-// Run runs the running aggregator, listens for incoming metrics, and waits
-// for period ticks to tell it when to push and reset the aggregator.
+// Run runs the running aggregator, listens for incoming metrics, and pushes
+// and resets the aggregator at the end of each period, after the delay.
+//
+// Periods are aligned to multiples of the period since the Unix epoch, so
+// that the periods of all the agents start at the same time: with a 1m
+// period, they start at the beginning of each minute. The first period is
+// shortened to the next boundary.
+//
+// A metric is added to the period containing its time. Metrics of the next
+// period received during the delay are kept until the current period is
+// pushed, and late metrics, whose period was already pushed, are handled
+// according to the late policy. Metrics more than a period ahead are
+// dropped.
+//
+// Aggregators implementing telegraf.DuePusher have their aggregates pushed
+// as soon as the delay has elapsed after their time, without waiting for
+// the end of the period.
 func (r *RunningAggregator) Run(
 	acc telegraf.Accumulator,
 	shutdown chan struct{},
 ) {
-	// The start of the period is truncated to the nearest second.
-	//
-	// Every metric then gets it's timestamp checked and is dropped if it
-	// is not within:
-	//
-	//   start < t < end + truncation + delay
-	//
-	// So if we start at now = 00:00.2 with a 10s period and 0.3s delay:
-	//   now = 00:00.2
-	//   start = 00:00
-	//   truncation = 00:00.2
-	//   end = 00:10
-	// 1st interval: 00:00 - 00:10.5
-	// 2nd interval: 00:10 - 00:20.5
-	// etc.
-	//
 	now := time.Now()
-	r.periodStart = now.Truncate(time.Second)
-	truncation := now.Sub(r.periodStart)
+	period := int64(r.Config.Period)
+	r.periodStart = time.Unix(0, now.UnixNano()-now.UnixNano()%period)
 	r.periodEnd = r.periodStart.Add(r.Config.Period)
-	time.Sleep(r.Config.Delay)
-	periodT := time.NewTicker(r.Config.Period)
-	defer periodT.Stop()
+	deadline := r.nextPush()
+	timer := time.NewTimer(deadline.Sub(now))
+	defer timer.Stop()
 
 	for {
 		select {
@@ -149,18 +374,81 @@ func (r *RunningAggregator) Run(
 			}
 			return
 		case m := <-r.metrics:
-			if m.Time().Before(r.periodStart) ||
-				m.Time().After(r.periodEnd.Add(truncation).Add(r.Config.Delay)) {
-				// the metric is outside the current aggregation period, so
-				// skip it.
-				continue
-			}
 			r.add(m)
-		case <-periodT.C:
-			r.periodStart = r.periodEnd
-			r.periodEnd = r.periodStart.Add(r.Config.Period)
-			r.push(acc)
-			r.reset()
+			if next := r.nextPush(); next.Before(deadline) {
+				if !timer.Stop() {
+					<-timer.C
+				}
+				deadline = next
+				timer.Reset(time.Until(deadline))
+			}
+		case <-timer.C:
+			if now := time.Now(); now.Before(r.periodEnd.Add(r.Config.Delay)) {
+				r.pushDue(acc, now)
+			} else {
+				r.push(acc)
+			}
+			deadline = r.nextPush()
+			timer.Reset(time.Until(deadline))
 		}
 	}
 }
+
+// periodAccumulator stamps the aggregates without a time with the end of
+// their period.
+type periodAccumulator struct {
+	telegraf.Accumulator
+	end time.Time
+}
+
+func (p *periodAccumulator) AddFields(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	p.Accumulator.AddFields(measurement, fields, tags, p.time(t))
+}
+
+func (p *periodAccumulator) AddGauge(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	p.Accumulator.AddGauge(measurement, fields, tags, p.time(t))
+}
+
+func (p *periodAccumulator) AddCounter(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	p.Accumulator.AddCounter(measurement, fields, tags, p.time(t))
+}
+
+func (p *periodAccumulator) AddSummary(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	p.Accumulator.AddSummary(measurement, fields, tags, p.time(t))
+}
+
+func (p *periodAccumulator) AddHistogram(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	p.Accumulator.AddHistogram(measurement, fields, tags, p.time(t))
+}
+
+func (p *periodAccumulator) time(t []time.Time) time.Time {
+	if len(t) > 0 {
+		return t[0]
+	}
+	return p.end
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/assert"
@@ -124,13 +125,47 @@ func TestAddAndPushOnePeriod(t *testing.T) {
 	)
 	assert.False(t, ra.Add(m))
 
+	// The metric may belong to the next period if the current one ends
+	// within 100ms.
 	for {
 		time.Sleep(time.Millisecond)
-		if acc.NMetrics() > 0 {
+		if acc.HasPoint("TestMetric", map[string]string{}, "sum", int64(101)) {
 			break
 		}
 	}
-	acc.AssertContainsFields(t, "TestMetric", map[string]interface{}{"sum": int64(101)})
+
+	close(shutdown)
+	wg.Wait()
+}
+
+func TestRunPushesDue(t *testing.T) {
+	a := &dueAggregator{}
+	ra := NewRunningAggregator(a, &AggregatorConfig{
+		Name:   "TestRunningAggregator",
+		Period: time.Hour,
+		Delay:  time.Millisecond * 20,
+	})
+	acc := testutil.Accumulator{}
+	shutdown := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ra.Run(&acc, shutdown)
+	}()
+
+	// The metric is pushed after the delay, long before the end of the
+	// period.
+	ra.Add(newValueMetric(1, time.Now().Unix()))
+	timeout := time.After(5 * time.Second)
+	for !acc.HasField("TestMetric", "value") {
+		select {
+		case <-timeout:
+			t.Fatal("metric not pushed before the end of the period")
+		case <-time.After(time.Millisecond):
+		}
+	}
 
 	close(shutdown)
 	wg.Wait()
@@ -166,6 +201,187 @@ func TestAddDropOriginal(t *testing.T) {
 	assert.False(t, ra.Add(m2))
 }
 
+func TestAddInputs(t *testing.T) {
+	ra := NewRunningAggregator(&TestAggregator{}, &AggregatorConfig{
+		Name:         "TestRunningAggregator",
+		Inputs:       InputScope{"frontend"},
+		DropOriginal: true,
+	})
+
+	m := newValueMetric(1, 600)
+	assert.False(t, ra.Add(m))
+	m.SetOrigin("frontend")
+	assert.True(t, ra.Add(m))
+}
+
+func newPeriodAggregator(policy string) (*RunningAggregator, *TestAggregator) {
+	a := &TestAggregator{}
+	ra := NewRunningAggregator(a, &AggregatorConfig{
+		Name:       "TestRunningAggregator",
+		Period:     time.Minute,
+		LatePolicy: policy,
+	})
+	ra.MetricsLate.Set(0)
+	ra.MetricsDropped.Set(0)
+	ra.periodStart = time.Unix(600, 0)
+	ra.periodEnd = time.Unix(660, 0)
+	return ra, a
+}
+
+func newValueMetric(value int64, sec int64) telegraf.Metric {
+	m, _ := metric.New("RITest",
+		map[string]string{},
+		map[string]interface{}{"value": value},
+		time.Unix(sec, 0),
+	)
+	return m
+}
+
+func TestAddPeriods(t *testing.T) {
+	ra, a := newPeriodAggregator(LatePolicyDrop)
+	acc := testutil.Accumulator{}
+
+	ra.add(newValueMetric(1, 600))
+	ra.add(newValueMetric(2, 659))
+	// next period
+	ra.add(newValueMetric(4, 660))
+	// more than a period ahead
+	ra.add(newValueMetric(8, 720))
+	// late
+	ra.add(newValueMetric(16, 599))
+	assert.Equal(t, int64(3), atomic.LoadInt64(&a.sum))
+	assert.Equal(t, int64(1), ra.MetricsLate.Get())
+	assert.Equal(t, int64(2), ra.MetricsDropped.Get())
+
+	ra.push(&acc)
+	assert.True(t, acc.HasTimestamp("TestMetric", time.Unix(660, 0)))
+	assert.Equal(t, time.Unix(660, 0), ra.periodStart)
+	assert.Equal(t, time.Unix(720, 0), ra.periodEnd)
+	assert.Equal(t, int64(4), atomic.LoadInt64(&a.sum))
+
+	acc.ClearMetrics()
+	ra.push(&acc)
+	acc.AssertContainsFields(t, "TestMetric", map[string]interface{}{"sum": int64(4)})
+	assert.True(t, acc.HasTimestamp("TestMetric", time.Unix(720, 0)))
+}
+
+func TestAddLatePolicyCurrent(t *testing.T) {
+	ra, a := newPeriodAggregator(LatePolicyCurrent)
+
+	ra.add(newValueMetric(1, 600))
+	ra.add(newValueMetric(2, 0))
+	assert.Equal(t, int64(3), atomic.LoadInt64(&a.sum))
+	assert.Equal(t, int64(1), ra.MetricsLate.Get())
+	assert.Equal(t, int64(0), ra.MetricsDropped.Get())
+}
+
+func TestAddLatePolicyCorrection(t *testing.T) {
+	ra, _ := newPeriodAggregator(LatePolicyCorrection)
+	acc := testutil.Accumulator{}
+
+	// The first period has no previous period to correct.
+	ra.add(newValueMetric(1, 599))
+	ra.add(newValueMetric(2, 600))
+	ra.push(&acc)
+	assert.Equal(t, uint64(1), acc.NMetrics())
+	acc.AssertContainsFields(t, "TestMetric", map[string]interface{}{"sum": int64(2)})
+
+	acc.ClearMetrics()
+	ra.add(newValueMetric(4, 660))
+	ra.add(newValueMetric(8, 659))
+	// before the previous period
+	ra.add(newValueMetric(16, 599))
+	ra.push(&acc)
+	assert.Equal(t, int64(3), ra.MetricsLate.Get())
+	assert.Equal(t, int64(2), ra.MetricsDropped.Get())
+	assert.Equal(t, uint64(2), acc.NMetrics())
+	assert.Equal(t, map[string]interface{}{"sum": int64(4)}, acc.Metrics[0].Fields)
+	assert.Equal(t, time.Unix(720, 0), acc.Metrics[0].Time)
+	assert.Equal(t, map[string]interface{}{"sum": int64(10)}, acc.Metrics[1].Fields)
+	assert.Equal(t, time.Unix(660, 0), acc.Metrics[1].Time)
+
+	// The corrected period is not corrected again.
+	acc.ClearMetrics()
+	ra.push(&acc)
+	assert.Equal(t, uint64(1), acc.NMetrics())
+}
+
+func TestSeriesLimits(t *testing.T) {
+	a := &expiringAggregator{}
+	ra := NewRunningAggregator(a, &AggregatorConfig{
+		Name:         "TestRunningAggregator",
+		Period:       time.Minute,
+		MaxSeries:    2,
+		SeriesExpiry: time.Hour,
+	})
+	ra.SeriesTracked.Set(0)
+	ra.SeriesEvicted.Set(0)
+	ra.SeriesExpired.Set(0)
+
+	now := time.Unix(600, 0)
+	ra.track(1, now)
+	ra.track(2, now.Add(time.Minute))
+	ra.track(1, now.Add(2*time.Minute))
+	// evicts the least recently updated series
+	ra.track(3, now.Add(3*time.Minute))
+	assert.Equal(t, []uint64{2}, a.expired)
+	assert.Equal(t, int64(2), ra.SeriesTracked.Get())
+	assert.Equal(t, int64(1), ra.SeriesEvicted.Get())
+
+	ra.expire(now.Add(time.Hour + 2*time.Minute))
+	assert.Equal(t, []uint64{2, 1}, a.expired)
+	assert.Equal(t, int64(1), ra.SeriesTracked.Get())
+	assert.Equal(t, int64(1), ra.SeriesExpired.Get())
+}
+
+func TestSeriesLimitsUnsupported(t *testing.T) {
+	ra := NewRunningAggregator(&TestAggregator{}, &AggregatorConfig{
+		Name:      "TestRunningAggregator",
+		Period:    time.Minute,
+		MaxSeries: 2,
+	})
+	assert.Nil(t, ra.expirer)
+}
+
+type expiringAggregator struct {
+	TestAggregator
+	expired []uint64
+}
+
+func (e *expiringAggregator) Expire(id uint64) {
+	e.expired = append(e.expired, id)
+}
+
+// dueAggregator pushes each metric added once it is due.
+type dueAggregator struct {
+	TestAggregator
+	pending []telegraf.Metric
+}
+
+func (d *dueAggregator) Add(in telegraf.Metric) {
+	d.pending = append(d.pending, in)
+}
+
+func (d *dueAggregator) PushUntil(
+	acc telegraf.Accumulator,
+	t time.Time,
+) time.Time {
+	var next time.Time
+	pending := d.pending[:0]
+	for _, m := range d.pending {
+		if m.Time().After(t) {
+			pending = append(pending, m)
+			if next.IsZero() || m.Time().Before(next) {
+				next = m.Time()
+			}
+			continue
+		}
+		acc.AddFields("TestMetric", m.Fields(), m.Tags(), m.Time())
+	}
+	d.pending = pending
+	return next
+}
+
 type TestAggregator struct {
 	sum int64
 }
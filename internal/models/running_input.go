@@ -1,7 +1,9 @@
 package models
 
 import (
+	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -15,10 +17,25 @@ type RunningInput struct {
 	Input  telegraf.Input
 	Config *InputConfig
 
+	// ID identifies the configuration the plugin was loaded from. Plugins
+	// loaded from identical configuration have the same ID.
+	ID string
+
 	trace       bool
 	defaultTags map[string]string
 
+	// gatherMu prevents concurrent gathers of the input
+	gatherMu sync.Mutex
+
+	// Outcome of the gathers, guarded by statusMu
+	statusMu   sync.Mutex
+	lastGather time.Time
+	lastError  error
+
 	MetricsGathered selfstat.Stat
+	// GatherSkipped counts the scheduled gathers that were skipped because
+	// the previous gather was still running.
+	GatherSkipped selfstat.Stat
 }
 
 func NewRunningInput(
@@ -33,6 +50,11 @@ func NewRunningInput(
 			"metrics_gathered",
 			map[string]string{"input": config.Name},
 		),
+		GatherSkipped: selfstat.Register(
+			"gather",
+			"gather_skipped",
+			map[string]string{"input": config.Name},
+		),
 	}
 }
 
@@ -45,12 +67,80 @@ type InputConfig struct {
 	Tags              map[string]string
 	Filter            Filter
 	Interval          time.Duration
+
+	// RoundInterval rounds the gathers to the interval, nil to use the
+	// round_interval of the agent.
+	RoundInterval *bool
+	// CollectionJitter delays each gather by a random amount, 0 to use the
+	// collection_jitter of the agent.
+	CollectionJitter time.Duration
+	// CollectionOffset shifts the gathers by a fixed amount.
+	CollectionOffset time.Duration
+	// GatherTimeout is the time after which a gather is canceled, 0 to use
+	// the time until the next gather.
+	GatherTimeout time.Duration
+	// Schedule is a cron expression at which times the input is gathered
+	// instead of every interval.
+	Schedule string
+	// Alias names the input for the processors and aggregators to select
+	// its metrics.
+	Alias string
+}
+
+// Origin returns the origin of the metrics of the input, its alias or its
+// name if it has none.
+func (c *InputConfig) Origin() string {
+	if c.Alias != "" {
+		return c.Alias
+	}
+	return c.Name
 }
 
 func (r *RunningInput) Name() string {
 	return "inputs." + r.Config.Name
 }
 
+// Gather gathers the metrics of the input and records the outcome. Gathers
+// of the same input are never run concurrently.
+func (r *RunningInput) Gather(acc telegraf.Accumulator) error {
+	return r.GatherContext(context.Background(), acc)
+}
+
+// GatherContext gathers like Gather. If the input is a telegraf.ContextInput,
+// the gather is canceled when ctx is done. If ctx is done while waiting for a
+// previous gather to return, the input is not gathered and ctx.Err() is
+// returned.
+func (r *RunningInput) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
+	r.gatherMu.Lock()
+	if err := ctx.Err(); err != nil {
+		r.gatherMu.Unlock()
+		return err
+	}
+	var err error
+	if input, ok := r.Input.(telegraf.ContextInput); ok {
+		err = input.GatherContext(ctx, acc)
+	} else {
+		err = r.Input.Gather(acc)
+	}
+	r.gatherMu.Unlock()
+
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	if err == nil {
+		r.lastGather = time.Now()
+	}
+	r.lastError = err
+	return err
+}
+
+// LastGather returns the time of the last successful gather and the error of
+// the last gather, if it failed.
+func (r *RunningInput) LastGather() (time.Time, error) {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	return r.lastGather, r.lastError
+}
+
 // MakeMetric either returns a metric, or returns nil if the metric doesn't
 // need to be created (because of filtering, an error, etc.)
 func (r *RunningInput) MakeMetric(
@@ -75,6 +165,10 @@ func (r *RunningInput) MakeMetric(
 		t,
 	)
 
+	if m != nil {
+		m.SetOrigin(r.Config.Origin())
+	}
+
 	if r.trace && m != nil {
 		s := influx.NewSerializer()
 		s.SetFieldSortOrder(influx.SortFields)
@@ -53,10 +53,27 @@ func TestMakeMetricNilFields(t *testing.T) {
 		now,
 	)
 	require.NoError(t, err)
+	expected.SetOrigin("TestRunningInput")
 
 	require.Equal(t, expected, m)
 }
 
+func TestMakeMetricOrigin(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:  "TestRunningInput",
+		Alias: "frontend",
+	})
+
+	m := ri.MakeMetric(
+		"RITest",
+		map[string]interface{}{"value": int(101)},
+		map[string]string{},
+		telegraf.Untyped,
+		time.Now(),
+	)
+	assert.Equal(t, "frontend", m.Origin())
+}
+
 func TestMakeMetricWithPluginTags(t *testing.T) {
 	now := time.Now()
 	ri := NewRunningInput(&testInput{}, &InputConfig{
@@ -87,6 +104,7 @@ func TestMakeMetricWithPluginTags(t *testing.T) {
 		now,
 	)
 	require.NoError(t, err)
+	expected.SetOrigin("TestRunningInput")
 	require.Equal(t, expected, m)
 }
 
@@ -143,6 +161,7 @@ func TestMakeMetricWithDaemonTags(t *testing.T) {
 		now,
 	)
 	require.NoError(t, err)
+	expected.SetOrigin("TestRunningInput")
 	require.Equal(t, expected, m)
 }
 
@@ -168,6 +187,7 @@ func TestMakeMetricNameOverride(t *testing.T) {
 		now,
 	)
 	require.NoError(t, err)
+	expected.SetOrigin("TestRunningInput")
 	require.Equal(t, expected, m)
 }
 
@@ -193,6 +213,7 @@ func TestMakeMetricNamePrefix(t *testing.T) {
 		now,
 	)
 	require.NoError(t, err)
+	expected.SetOrigin("TestRunningInput")
 	require.Equal(t, expected, m)
 }
 
@@ -218,6 +239,7 @@ func TestMakeMetricNameSuffix(t *testing.T) {
 		now,
 	)
 	require.NoError(t, err)
+	expected.SetOrigin("TestRunningInput")
 	require.Equal(t, expected, m)
 }
 
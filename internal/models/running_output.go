@@ -1,7 +1,9 @@
 package models
 
 import (
+	"fmt"
 	"log"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -19,6 +21,16 @@ const (
 	DEFAULT_METRIC_BUFFER_LIMIT = 10000
 )
 
+// Reasons for dropping a metric reported to the dead letter output.
+const (
+	DropReasonOverflow = "overflow"
+	DropReasonFiltered = "filtered"
+	DropReasonRejected = "rejected"
+)
+
+// DropReasons are all reasons for dropping a metric.
+var DropReasons = []string{DropReasonOverflow, DropReasonFiltered, DropReasonRejected}
+
 // RunningOutput contains the output configuration
 type RunningOutput struct {
 	Name              string
@@ -27,14 +39,47 @@ type RunningOutput struct {
 	MetricBufferLimit int
 	MetricBatchSize   int
 
+	// Processors are applied to the metrics of this output only, before its
+	// filter.
+	Processors RunningProcessors
+
+	// processorsIn queues the metrics of the processors while they run as a
+	// pipeline, and processorsDone is closed once the pipeline is drained.
+	// Both are nil when the pipeline is not running and guarded by
+	// processorsMu.
+	processorsMu   sync.RWMutex
+	processorsIn   chan telegraf.Metric
+	processorsDone chan struct{}
+
+	// ID identifies the configuration the plugin was loaded from. Plugins
+	// loaded from identical configuration have the same ID.
+	ID string
+
 	MetricsFiltered selfstat.Stat
 	MetricsWritten  selfstat.Stat
+	MetricsRejected selfstat.Stat
 	BufferSize      selfstat.Stat
 	BufferLimit     selfstat.Stat
 	WriteTime       selfstat.Stat
 
-	metrics     *buffer.Buffer
-	failMetrics *buffer.Buffer
+	metrics     buffer.Buffer
+	failMetrics buffer.Buffer
+	// batchMu serializes taking batches from the buffers, writing them and
+	// accepting them, so that a batch is only accepted once written or
+	// added back to failMetrics.
+	batchMu sync.Mutex
+
+	deadLetter        *RunningOutput
+	deadLetterReasons map[string]bool
+
+	// Outcome of the writes, guarded by statusMu
+	statusMu  sync.Mutex
+	lastWrite time.Time
+	lastError error
+
+	// pending receives the result of a write that timed out, guarded by the
+	// embedded Mutex.
+	pending chan error
 
 	// Guards against concurrent calls to the Output as described in #3009
 	sync.Mutex
@@ -71,6 +116,11 @@ func NewRunningOutput(
 			"metrics_filtered",
 			map[string]string{"output": name},
 		),
+		MetricsRejected: selfstat.Register(
+			"write",
+			"metrics_rejected",
+			map[string]string{"output": name},
+		),
 		BufferSize: selfstat.Register(
 			"write",
 			"buffer_size",
@@ -91,12 +141,124 @@ func NewRunningOutput(
 	return ro
 }
 
-// AddMetric adds a metric to the output. This function can also write cached
-// points if FlushBufferWhenFull is true.
+// OpenBuffer replaces the in-memory buffers of the output with disk buffers
+// when a buffer_path is configured, replaying the metrics left by a previous
+// run. If the disk buffers can't be opened, the output keeps buffering in
+// memory. It must be called before any metric is added to the output.
+func (ro *RunningOutput) OpenBuffer() {
+	conf := ro.Config
+	if conf == nil || conf.BufferPath == "" {
+		return
+	}
+
+	segmentSize := conf.BufferSegmentSize
+	if segmentSize == 0 {
+		segmentSize = ro.MetricBatchSize
+	}
+	metrics, err := buffer.NewDiskBuffer(filepath.Join(conf.BufferPath, "metrics"),
+		ro.MetricBatchSize, ro.MetricBatchSize, 0)
+	if err != nil {
+		log.Printf("E! Output [%s] unable to open disk buffer, falling back "+
+			"to memory: %s", ro.Name, err)
+		return
+	}
+	failMetrics, err := buffer.NewDiskBuffer(filepath.Join(conf.BufferPath, "failed"),
+		ro.MetricBufferLimit, segmentSize, conf.BufferMaxBytes)
+	if err != nil {
+		log.Printf("E! Output [%s] unable to open disk buffer, falling back "+
+			"to memory: %s", ro.Name, err)
+		metrics.Close()
+		return
+	}
+
+	if n := metrics.Len() + failMetrics.Len(); n > 0 {
+		log.Printf("I! Output [%s] replayed %d buffered metrics from %s",
+			ro.Name, n, conf.BufferPath)
+	}
+	ro.metrics, ro.failMetrics = metrics, failMetrics
+	if ro.deadLetter != nil {
+		ro.setDropHandlers()
+	}
+}
+
+// AddMetric applies the processors of the output to a metric and adds the
+// results to the output. While the processors run as a pipeline, the metric
+// is only queued to them. This function can also write cached points if
+// FlushBufferWhenFull is true.
 func (ro *RunningOutput) AddMetric(m telegraf.Metric) {
 	if m == nil {
 		return
 	}
+	if len(ro.Processors) == 0 {
+		ro.addMetric(m)
+		return
+	}
+
+	ro.processorsMu.RLock()
+	defer ro.processorsMu.RUnlock()
+	if ro.processorsIn != nil {
+		ro.processorsIn <- m
+		return
+	}
+
+	metrics := []telegraf.Metric{m}
+	for _, processor := range ro.Processors {
+		metrics = processor.Apply(metrics...)
+	}
+	for _, m := range metrics {
+		ro.addMetric(m)
+	}
+}
+
+// StartProcessors runs the processors of the output, and of its dead letter
+// output, as a pipeline: each processor is a stage running in its own
+// goroutine, so that applying them does not hold the caller of AddMetric.
+func (ro *RunningOutput) StartProcessors() {
+	if ro.deadLetter != nil {
+		ro.deadLetter.StartProcessors()
+	}
+
+	ro.processorsMu.Lock()
+	defer ro.processorsMu.Unlock()
+	if len(ro.Processors) == 0 || ro.processorsIn != nil {
+		return
+	}
+
+	ro.processorsIn = make(chan telegraf.Metric, 100)
+	ro.processorsDone = make(chan struct{})
+	var src <-chan telegraf.Metric = ro.processorsIn
+	for _, rp := range ro.Processors {
+		dst := make(chan telegraf.Metric, 100)
+		go rp.Run(src, dst)
+		src = dst
+	}
+	go func(done chan struct{}) {
+		defer close(done)
+		for m := range src {
+			ro.addMetric(m)
+		}
+	}(ro.processorsDone)
+}
+
+// StopProcessors stops the pipeline of the processors once the metrics
+// queued are added to the output, then that of the dead letter output. The
+// processors are then applied by AddMetric.
+func (ro *RunningOutput) StopProcessors() {
+	ro.processorsMu.Lock()
+	if ro.processorsIn != nil {
+		close(ro.processorsIn)
+		<-ro.processorsDone
+		ro.processorsIn = nil
+		ro.processorsDone = nil
+	}
+	ro.processorsMu.Unlock()
+
+	if ro.deadLetter != nil {
+		ro.deadLetter.StopProcessors()
+	}
+}
+
+func (ro *RunningOutput) addMetric(m telegraf.Metric) {
 	// Filter any tagexclude/taginclude parameters before adding metric
 	if ro.Config.Filter.IsActive() {
 		// In order to filter out tags, we need to create a new metric, since
@@ -107,6 +269,7 @@ func (ro *RunningOutput) AddMetric(m telegraf.Metric) {
 		t := m.Time()
 		if ok := ro.Config.Filter.Apply(name, fields, tags); !ok {
 			ro.MetricsFiltered.Incr(1)
+			ro.drop(m, DropReasonFiltered)
 			return
 		}
 		// error is not possible if creating from another metric, so ignore.
@@ -115,16 +278,63 @@ func (ro *RunningOutput) AddMetric(m telegraf.Metric) {
 
 	ro.metrics.Add(m)
 	if ro.metrics.Len() == ro.MetricBatchSize {
+		ro.batchMu.Lock()
+		defer ro.batchMu.Unlock()
 		batch := ro.metrics.Batch(ro.MetricBatchSize)
-		err := ro.write(batch)
+		retry, err := ro.write(batch)
 		if err != nil {
-			ro.failMetrics.Add(batch...)
+			ro.failMetrics.Add(retry...)
 		}
+		ro.metrics.Accept()
 	}
 }
 
+// SetDeadLetter sets the output that receives the metrics dropped by this
+// output for any of the given reasons.
+func (ro *RunningOutput) SetDeadLetter(dl *RunningOutput, reasons []string) {
+	ro.deadLetter = dl
+	ro.deadLetterReasons = make(map[string]bool, len(reasons))
+	for _, reason := range reasons {
+		ro.deadLetterReasons[reason] = true
+	}
+	ro.setDropHandlers()
+}
+
+// setDropHandlers sends the metrics dropped by the buffers to the dead letter
+// output.
+func (ro *RunningOutput) setDropHandlers() {
+	overflow := func(m telegraf.Metric) {
+		ro.drop(m, DropReasonOverflow)
+	}
+	ro.metrics.SetDropHandler(overflow)
+	ro.failMetrics.SetDropHandler(overflow)
+}
+
+// DeadLetter returns the dead letter output, or nil if none is set.
+func (ro *RunningOutput) DeadLetter() *RunningOutput {
+	return ro.deadLetter
+}
+
+// drop sends a metric dropped by this output to the dead letter output, tagged
+// with the reason and the name of this output.
+func (ro *RunningOutput) drop(m telegraf.Metric, reason string) {
+	if ro.deadLetter == nil || !ro.deadLetterReasons[reason] {
+		return
+	}
+	m = m.Copy()
+	m.AddTag("dead_letter_reason", reason)
+	m.AddTag("dead_letter_output", ro.Name)
+	ro.deadLetter.AddMetric(m)
+}
+
 // Write writes all cached points to this output.
 func (ro *RunningOutput) Write() error {
+	if ro.deadLetter != nil {
+		defer ro.writeDeadLetter()
+	}
+	ro.batchMu.Lock()
+	defer ro.batchMu.Unlock()
+
 	nFails, nMetrics := ro.failMetrics.Len(), ro.metrics.Len()
 	ro.BufferSize.Set(int64(nFails + nMetrics))
 	log.Printf("D! Output [%s] buffer fullness: %d / %d metrics. ",
@@ -146,11 +356,12 @@ func (ro *RunningOutput) Write() error {
 			// write to this output again. We are not exiting the loop just so
 			// that we can rotate the metrics to preserve order.
 			if err == nil {
-				err = ro.write(batch)
+				batch, err = ro.write(batch)
 			}
 			if err != nil {
 				ro.failMetrics.Add(batch...)
 			}
+			ro.failMetrics.Accept()
 		}
 	}
 
@@ -158,31 +369,189 @@ func (ro *RunningOutput) Write() error {
 	// see comment above about not trying to write to an already failed output.
 	// if ro.failMetrics is empty then err will always be nil at this point.
 	if err == nil {
-		err = ro.write(batch)
+		batch, err = ro.write(batch)
 	}
 
 	if err != nil {
 		ro.failMetrics.Add(batch...)
-		return err
 	}
-	return nil
+	ro.metrics.Accept()
+	return err
+}
+
+// setStatus records the outcome of a write.
+func (ro *RunningOutput) setStatus(err *error) {
+	ro.statusMu.Lock()
+	defer ro.statusMu.Unlock()
+	if *err == nil {
+		ro.lastWrite = time.Now()
+	}
+	ro.lastError = *err
+}
+
+// LastWrite returns the time of the last successful write and the error of
+// the last write, if it failed.
+func (ro *RunningOutput) LastWrite() (time.Time, error) {
+	ro.statusMu.Lock()
+	defer ro.statusMu.Unlock()
+	return ro.lastWrite, ro.lastError
+}
+
+// BufferLen returns the number of metrics waiting to be written.
+func (ro *RunningOutput) BufferLen() int {
+	return ro.metrics.Len() + ro.failMetrics.Len()
+}
+
+// writeDeadLetter writes the metrics dropped by this output to the dead letter
+// output.
+func (ro *RunningOutput) writeDeadLetter() {
+	if err := ro.deadLetter.Write(); err != nil {
+		log.Printf("E! Error writing to dead letter output [%s]: %s\n",
+			ro.deadLetter.Name, err.Error())
+	}
 }
 
-func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
+// write writes a batch of metrics to the output. It returns the metrics of the
+// batch that need to be retried along with the write error, if any.
+func (ro *RunningOutput) write(metrics []telegraf.Metric) (retry []telegraf.Metric, err error) {
 	nMetrics := len(metrics)
 	if nMetrics == 0 {
-		return nil
+		return nil, nil
 	}
 	ro.Lock()
 	defer ro.Unlock()
+	defer ro.setStatus(&err)
+	if ro.pending != nil {
+		select {
+		case <-ro.pending:
+			ro.pending = nil
+		default:
+			return metrics, fmt.Errorf("previous write is still in progress")
+		}
+	}
 	start := time.Now()
-	err := ro.Output.Write(metrics)
+	err = ro.writeTimeout(metrics)
 	elapsed := time.Since(start)
 	if err == nil {
 		log.Printf("D! Output [%s] wrote batch of %d metrics in %s\n",
 			ro.Name, nMetrics, elapsed)
 		ro.MetricsWritten.Incr(int64(nMetrics))
 		ro.WriteTime.Incr(elapsed.Nanoseconds())
+		return nil, nil
+	}
+
+	if perr, ok := err.(*telegraf.PartialWriteError); ok {
+		return ro.partialWrite(metrics, perr, elapsed)
+	}
+	return metrics, err
+}
+
+// writeTimeout writes the metrics to the output, giving up after the write
+// timeout. The output is not written again until the abandoned write returns.
+// As the abandoned write may still succeed, its metrics can be written twice.
+func (ro *RunningOutput) writeTimeout(metrics []telegraf.Metric) error {
+	if ro.Config.WriteTimeout <= 0 {
+		return ro.Output.Write(metrics)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- ro.Output.Write(metrics)
+	}()
+
+	timer := time.NewTimer(ro.Config.WriteTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		ro.pending = done
+		return fmt.Errorf("write timed out after %s", ro.Config.WriteTimeout)
+	}
+}
+
+// WaitWrite waits up to the write timeout for a write that timed out to
+// return, so that the output can be written once more before it is closed.
+func (ro *RunningOutput) WaitWrite() {
+	ro.Lock()
+	defer ro.Unlock()
+	if ro.pending == nil {
+		return
+	}
+
+	timer := time.NewTimer(ro.Config.WriteTimeout)
+	defer timer.Stop()
+	select {
+	case <-ro.pending:
+		ro.pending = nil
+	case <-timer.C:
+	}
+}
+
+// partialWrite accounts for the accepted and rejected metrics of a partially
+// written batch, and returns the metrics that need to be retried. The error is
+// only returned if there are metrics to retry.
+func (ro *RunningOutput) partialWrite(
+	metrics []telegraf.Metric,
+	perr *telegraf.PartialWriteError,
+	elapsed time.Duration,
+) ([]telegraf.Metric, error) {
+	done := make([]bool, len(metrics))
+	var nAccept int
+	for _, i := range perr.MetricsAccept {
+		if i >= 0 && i < len(metrics) && !done[i] {
+			done[i] = true
+			nAccept++
+		}
+	}
+	rejected := make([]telegraf.Metric, 0, len(perr.MetricsReject))
+	for _, i := range perr.MetricsReject {
+		if i >= 0 && i < len(metrics) && !done[i] {
+			done[i] = true
+			rejected = append(rejected, metrics[i])
+		}
+	}
+
+	retry := make([]telegraf.Metric, 0, len(metrics)-nAccept-len(rejected))
+	for i, m := range metrics {
+		if !done[i] {
+			retry = append(retry, m)
+		}
+	}
+	for _, m := range rejected {
+		ro.drop(m, DropReasonRejected)
+	}
+
+	ro.MetricsWritten.Incr(int64(nAccept))
+	ro.MetricsRejected.Incr(int64(len(rejected)))
+	ro.WriteTime.Incr(elapsed.Nanoseconds())
+	log.Printf("D! Output [%s] wrote %d of %d metrics in %s\n",
+		ro.Name, nAccept, len(metrics), elapsed)
+	if len(rejected) > 0 {
+		log.Printf("E! Output [%s] permanently rejected %d metrics, dropping "+
+			"them: %v\n", ro.Name, len(rejected), perr.Err)
+	}
+
+	if len(retry) == 0 {
+		return nil, nil
+	}
+	return retry, perr
+}
+
+// Close closes the output, its dead letter output and its buffers. Metrics
+// remaining in a disk buffer are kept for the next start.
+func (ro *RunningOutput) Close() error {
+	err := ro.Output.Close()
+	if berr := ro.metrics.Close(); err == nil {
+		err = berr
+	}
+	if berr := ro.failMetrics.Close(); err == nil {
+		err = berr
+	}
+	if ro.deadLetter != nil {
+		if derr := ro.deadLetter.Close(); err == nil {
+			err = derr
+		}
 	}
 	return err
 }
@@ -191,4 +560,23 @@ func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
 type OutputConfig struct {
 	Name   string
 	Filter Filter
+
+	// BufferPath is the directory of the disk buffer. If empty, metrics are
+	// only buffered in memory.
+	BufferPath string
+	// BufferSegmentSize is the number of metrics per disk buffer segment.
+	BufferSegmentSize int
+	// BufferMaxBytes is the maximum disk usage of the disk buffer, 0 for no
+	// limit.
+	BufferMaxBytes int64
+
+	// FlushInterval is the interval at which the output is written, 0 to
+	// use the flush_interval of the agent.
+	FlushInterval time.Duration
+	// FlushJitter jitters the flush interval by a random amount, 0 to use
+	// the flush_jitter of the agent.
+	FlushJitter time.Duration
+	// WriteTimeout is the time after which a write is abandoned, 0 for no
+	// timeout.
+	WriteTimeout time.Duration
 }
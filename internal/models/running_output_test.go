@@ -2,8 +2,11 @@ package models
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
@@ -117,6 +120,66 @@ func TestRunningOutput_DropFilter(t *testing.T) {
 	assert.Len(t, m.Metrics(), 8)
 }
 
+// Test that the processors of the output are applied before its filter.
+func TestRunningOutput_Processors(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{
+			NameDrop: []string{"fuz"},
+		},
+	}
+	assert.NoError(t, conf.Filter.Compile())
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+	ro.Processors = RunningProcessors{NewTestRunningProcessor()}
+
+	ro.AddMetric(testutil.TestMetric(1, "foo"))
+	ro.AddMetric(testutil.TestMetric(1, "bar"))
+	ro.AddMetric(testutil.TestMetric(1, "dropme"))
+
+	err := ro.Write()
+	assert.NoError(t, err)
+	require.Len(t, m.Metrics(), 1)
+	assert.Equal(t, "baz", m.Metrics()[0].Name())
+}
+
+// Test that the processors of the output run as a pipeline once started,
+// reporting their statistics with the output tag.
+func TestRunningOutput_ProcessorsPipeline(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{
+			NameDrop: []string{"fuz"},
+		},
+	}
+	assert.NoError(t, conf.Filter.Compile())
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+	rp := NewRunningProcessor("test",
+		&ProcessorConfig{Filter: Filter{}, Output: "test"},
+		&TestProcessor{},
+	)
+	ro.Processors = RunningProcessors{rp}
+
+	ro.StartProcessors()
+	ro.AddMetric(testutil.TestMetric(1, "foo"))
+	ro.AddMetric(testutil.TestMetric(1, "bar"))
+	ro.StopProcessors()
+
+	err := ro.Write()
+	assert.NoError(t, err)
+	require.Len(t, m.Metrics(), 1)
+	assert.Equal(t, "baz", m.Metrics()[0].Name())
+	assert.Equal(t, map[string]string{"processor": "test", "output": "test"},
+		rp.ProcessTime.Tags())
+
+	// Once stopped, the processors are applied by AddMetric.
+	ro.AddMetric(testutil.TestMetric(1, "bar"))
+	err = ro.Write()
+	assert.NoError(t, err)
+	require.Len(t, m.Metrics(), 2)
+}
+
 // Test that NameDrop filters without a match do nothing.
 func TestRunningOutput_PassFilter(t *testing.T) {
 	conf := &OutputConfig{
@@ -330,6 +393,41 @@ func TestRunningOutputWriteFail(t *testing.T) {
 	assert.Len(t, m.Metrics(), 10)
 }
 
+// Test that metrics in a disk buffer survive a restart of the output.
+func TestRunningOutputDiskBufferReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-output")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := &OutputConfig{
+		Filter:     Filter{},
+		BufferPath: dir,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 4, 12)
+	ro.OpenBuffer()
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+	require.NoError(t, ro.Close())
+
+	m = &mockOutput{}
+	ro = NewRunningOutput("test", m, conf, 4, 12)
+	ro.OpenBuffer()
+	defer ro.Close()
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 10)
+	for i, metric := range append(first5, next5...) {
+		assert.Equal(t, metric.Name(), m.Metrics()[i].Name())
+	}
+}
+
 // Verify that the order of points is preserved during a write failure.
 func TestRunningOutputWriteFailOrder(t *testing.T) {
 	conf := &OutputConfig{
@@ -479,6 +577,141 @@ func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	assert.Equal(t, expected, m.Metrics())
 }
 
+// Verify that rejected metrics are dropped and only unacknowledged metrics
+// are retried after a partial write.
+func TestRunningOutputPartialWrite(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	m := &partialOutput{
+		reject: map[string]bool{"metric2": true, "metric7": true},
+		retry:  map[string]bool{"metric4": true},
+	}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+
+	err := ro.Write()
+	require.Error(t, err)
+	assert.Len(t, m.Metrics(), 7)
+	assert.Equal(t, int64(2), ro.MetricsRejected.Get())
+
+	m.retry = nil
+	err = ro.Write()
+	require.NoError(t, err)
+	require.Len(t, m.Metrics(), 8)
+	assert.Equal(t, "metric4", m.Metrics()[7].Name())
+	assert.Equal(t, int64(2), ro.MetricsRejected.Get())
+}
+
+func TestRunningOutputDeadLetter(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{
+			NameDrop: []string{"metric1"},
+		},
+	}
+	assert.NoError(t, conf.Filter.Compile())
+
+	m := &partialOutput{
+		reject: map[string]bool{"metric5": true},
+		retry:  map[string]bool{"metric2": true, "metric3": true, "metric4": true},
+	}
+	ro := NewRunningOutput("test", m, conf, 1000, 2)
+
+	dl := &mockOutput{}
+	ro.SetDeadLetter(NewRunningOutput("dl", dl, &OutputConfig{}, 1000, 10000),
+		DropReasons)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+	assert.Len(t, m.Metrics(), 0)
+
+	m.retry = nil
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 2)
+	assert.Equal(t, "metric3", m.Metrics()[0].Name())
+	assert.Equal(t, "metric4", m.Metrics()[1].Name())
+
+	reasons := map[string]string{}
+	for _, metric := range dl.Metrics() {
+		tags := metric.Tags()
+		assert.Equal(t, "test", tags["dead_letter_output"])
+		reasons[metric.Name()] = tags["dead_letter_reason"]
+	}
+	assert.Equal(t, map[string]string{
+		"metric1": DropReasonFiltered,
+		"metric2": DropReasonOverflow,
+		"metric5": DropReasonRejected,
+	}, reasons)
+	assert.False(t, first5[0].HasTag("dead_letter_reason"))
+}
+
+// Test that a write exceeding the write timeout keeps the batch, and that
+// the output is not written again until the timed out write returned.
+func TestRunningOutputWriteTimeout(t *testing.T) {
+	conf := &OutputConfig{
+		WriteTimeout: 10 * time.Millisecond,
+	}
+
+	m := &blockingOutput{release: make(chan struct{})}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+	ro.MetricsWritten.Set(0)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	assert.Error(t, ro.Write())
+	assert.Equal(t, 5, ro.BufferLen())
+
+	start := time.Now()
+	assert.Error(t, ro.Write())
+	assert.True(t, time.Since(start) < conf.WriteTimeout)
+	assert.Equal(t, 5, ro.BufferLen())
+
+	close(m.release)
+	var err error
+	for i := 0; i < 100; i++ {
+		if err = ro.Write(); err == nil {
+			break
+		}
+		time.Sleep(conf.WriteTimeout)
+	}
+	require.NoError(t, err)
+	assert.Equal(t, 0, ro.BufferLen())
+	assert.Equal(t, int64(5), ro.MetricsWritten.Get())
+}
+
+// Test that WaitWrite waits for a timed out write to return, so that the
+// output can be written again.
+func TestRunningOutputWaitWrite(t *testing.T) {
+	conf := &OutputConfig{
+		WriteTimeout: 100 * time.Millisecond,
+	}
+
+	m := &blockingOutput{release: make(chan struct{})}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+	ro.MetricsWritten.Set(0)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	assert.Error(t, ro.Write())
+
+	time.AfterFunc(10*time.Millisecond, func() { close(m.release) })
+	ro.WaitWrite()
+	require.NoError(t, ro.Write())
+	assert.Equal(t, 0, ro.BufferLen())
+	assert.Equal(t, int64(5), ro.MetricsWritten.Get())
+}
+
 type mockOutput struct {
 	sync.Mutex
 
@@ -554,3 +787,55 @@ func (m *perfOutput) Write(metrics []telegraf.Metric) error {
 	}
 	return nil
 }
+
+// partialOutput rejects and asks to retry metrics by name.
+type partialOutput struct {
+	mockOutput
+
+	reject map[string]bool
+	retry  map[string]bool
+}
+
+func (m *partialOutput) Write(metrics []telegraf.Metric) error {
+	m.Lock()
+	defer m.Unlock()
+
+	perr := &telegraf.PartialWriteError{Err: fmt.Errorf("Partial Write!")}
+	for i, metric := range metrics {
+		switch {
+		case m.reject[metric.Name()]:
+			perr.MetricsReject = append(perr.MetricsReject, i)
+		case m.retry[metric.Name()]:
+		default:
+			perr.MetricsAccept = append(perr.MetricsAccept, i)
+			m.metrics = append(m.metrics, metric)
+		}
+	}
+	return perr
+}
+
+// blockingOutput blocks in Write until release is closed.
+type blockingOutput struct {
+	release chan struct{}
+}
+
+func (m *blockingOutput) Connect() error {
+	return nil
+}
+
+func (m *blockingOutput) Close() error {
+	return nil
+}
+
+func (m *blockingOutput) Description() string {
+	return ""
+}
+
+func (m *blockingOutput) SampleConfig() string {
+	return ""
+}
+
+func (m *blockingOutput) Write(metrics []telegraf.Metric) error {
+	<-m.release
+	return nil
+}
@@ -2,16 +2,45 @@ package models
 
 import (
 	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// Orderings of the metrics applied by several workers.
+const (
+	// OrderingSeries sends the metrics of a series to the same worker, which
+	// keeps their order.
+	OrderingSeries = "series"
+	// OrderingNone sends the metrics to any available worker.
+	OrderingNone = "none"
 )
 
 type RunningProcessor struct {
 	Name string
 
-	sync.Mutex
+	// ID identifies the configuration the plugin was loaded from. Plugins
+	// loaded from identical configuration have the same ID.
+	ID string
+
+	// Processor is the instance of the processor used by the first worker.
 	Processor telegraf.Processor
 	Config    *ProcessorConfig
+
+	workers []*processorWorker
+
+	// ProcessTime is the time taken to apply the processor to a metric, and
+	// QueueDepth the number of metrics waiting to be applied.
+	ProcessTime selfstat.Stat
+	QueueDepth  selfstat.Stat
+}
+
+// processorWorker is an instance of a processor. It is locked while applied,
+// as the processors of the inputs and of the aggregators are the same.
+type processorWorker struct {
+	sync.Mutex
+	processor telegraf.Processor
 }
 
 type RunningProcessors []*RunningProcessor
@@ -25,15 +54,62 @@ type ProcessorConfig struct {
 	Name   string
 	Order  int64
 	Filter Filter
+	Inputs InputScope
+
+	// Workers is the number of goroutines applying the processor, and
+	// Ordering how the metrics are distributed among them, OrderingSeries
+	// if empty.
+	Workers  int
+	Ordering string
+
+	// Output is the name of the output if the processor is applied to the
+	// metrics of a single output, reported as a tag of its statistics.
+	Output string
+}
+
+// NewRunningProcessor returns a RunningProcessor applying the given instances
+// of a processor, one per worker.
+func NewRunningProcessor(
+	name string,
+	conf *ProcessorConfig,
+	processors ...telegraf.Processor,
+) *RunningProcessor {
+	tags := map[string]string{"processor": name}
+	if conf.Output != "" {
+		tags["output"] = conf.Output
+	}
+	rp := &RunningProcessor{
+		Name:        name,
+		Processor:   processors[0],
+		Config:      conf,
+		ProcessTime: selfstat.RegisterTiming("process", "process_time_ns", tags),
+		QueueDepth:  selfstat.Register("process", "queue_depth", tags),
+	}
+	for _, p := range processors {
+		rp.workers = append(rp.workers, &processorWorker{processor: p})
+	}
+	return rp
 }
 
+// Apply applies the processor of the first worker to the metrics.
 func (rp *RunningProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
-	rp.Lock()
-	defer rp.Unlock()
+	return rp.apply(rp.workers[0], in...)
+}
+
+func (rp *RunningProcessor) apply(
+	w *processorWorker,
+	in ...telegraf.Metric,
+) []telegraf.Metric {
+	w.Lock()
+	defer w.Unlock()
 
 	ret := []telegraf.Metric{}
 
 	for _, metric := range in {
+		if !rp.Config.Inputs.Contains(metric) {
+			ret = append(ret, metric)
+			continue
+		}
 		if rp.Config.Filter.IsActive() {
 			// check if the filter should be applied to this metric
 			if ok := rp.Config.Filter.Apply(metric.Name(), metric.Fields(), metric.Tags()); !ok {
@@ -44,8 +120,70 @@ func (rp *RunningProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
 		}
 		// This metric should pass through the filter, so call the filter Apply
 		// function and append results to the output slice.
-		ret = append(ret, rp.Processor.Apply(metric)...)
+		ret = append(ret, w.processor.Apply(metric)...)
 	}
 
 	return ret
 }
+
+// Run applies the processor to the metrics received from in and sends the
+// results to out, until in is closed and drained. It then closes out.
+//
+// With several workers, the metrics of a series are sent to the same worker
+// with the series ordering, so that their order is kept, and to any
+// available worker otherwise.
+func (rp *RunningProcessor) Run(
+	in <-chan telegraf.Metric,
+	out chan<- telegraf.Metric,
+) {
+	var wg sync.WaitGroup
+	if len(rp.workers) == 1 || rp.Config.Ordering == OrderingNone {
+		for _, w := range rp.workers {
+			wg.Add(1)
+			go func(w *processorWorker) {
+				defer wg.Done()
+				rp.work(w, in, out, true)
+			}(w)
+		}
+	} else {
+		shards := make([]chan telegraf.Metric, len(rp.workers))
+		for i, w := range rp.workers {
+			shards[i] = make(chan telegraf.Metric, cap(in))
+			wg.Add(1)
+			go func(w *processorWorker, shard <-chan telegraf.Metric) {
+				defer wg.Done()
+				rp.work(w, shard, out, false)
+			}(w, shards[i])
+		}
+		for m := range in {
+			rp.QueueDepth.Set(int64(len(in)))
+			shards[m.HashID()%uint64(len(shards))] <- m
+		}
+		for _, shard := range shards {
+			close(shard)
+		}
+	}
+	wg.Wait()
+	close(out)
+}
+
+// work applies the processor of the worker to the metrics received from in,
+// reporting the depth of in if it is the queue of the processor.
+func (rp *RunningProcessor) work(
+	w *processorWorker,
+	in <-chan telegraf.Metric,
+	out chan<- telegraf.Metric,
+	queue bool,
+) {
+	for m := range in {
+		if queue {
+			rp.QueueDepth.Set(int64(len(in)))
+		}
+		start := time.Now()
+		metrics := rp.apply(w, m)
+		rp.ProcessTime.Incr(time.Since(start).Nanoseconds())
+		for _, m := range metrics {
+			out <- m
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/influxdata/telegraf"
@@ -37,11 +38,10 @@ func (f *TestProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
 }
 
 func NewTestRunningProcessor() *RunningProcessor {
-	out := &RunningProcessor{
-		Name:      "test",
-		Processor: &TestProcessor{},
-		Config:    &ProcessorConfig{Filter: Filter{}},
-	}
+	out := NewRunningProcessor("test",
+		&ProcessorConfig{Filter: Filter{}},
+		&TestProcessor{},
+	)
 	return out
 }
 
@@ -115,3 +115,90 @@ func TestRunningProcessor_DroppedMetric(t *testing.T) {
 	}
 	assert.Equal(t, expectedNames, actualNames)
 }
+
+func TestRunningProcessor_Inputs(t *testing.T) {
+	frontend := testutil.TestMetric(1, "foo")
+	frontend.SetOrigin("frontend")
+	inmetrics := []telegraf.Metric{
+		frontend,
+		testutil.TestMetric(1, "bar"),
+	}
+
+	rfp := NewTestRunningProcessor()
+	rfp.Config.Inputs = InputScope{"frontend"}
+	filteredMetrics := rfp.Apply(inmetrics...)
+
+	assert.Equal(t, "fuz", filteredMetrics[0].Name())
+	assert.Equal(t, "bar", filteredMetrics[1].Name())
+}
+
+// recordingProcessor passes the metrics through, recording them.
+type recordingProcessor struct {
+	metrics []telegraf.Metric
+}
+
+func (r *recordingProcessor) SampleConfig() string { return "" }
+func (r *recordingProcessor) Description() string  { return "" }
+
+func (r *recordingProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	r.metrics = append(r.metrics, in...)
+	return in
+}
+
+func runProcessor(rp *RunningProcessor, n int) []telegraf.Metric {
+	in := make(chan telegraf.Metric, 10)
+	out := make(chan telegraf.Metric, 10)
+	go func() {
+		for i := 0; i < n; i++ {
+			in <- testutil.TestMetric(i, fmt.Sprintf("series%d", i%8))
+		}
+		close(in)
+	}()
+	go rp.Run(in, out)
+
+	var metrics []telegraf.Metric
+	for m := range out {
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+func TestRunningProcessor_RunSeriesOrdering(t *testing.T) {
+	workers := []*recordingProcessor{{}, {}, {}}
+	rp := NewRunningProcessor("test",
+		&ProcessorConfig{Workers: 3, Ordering: OrderingSeries},
+		workers[0], workers[1], workers[2],
+	)
+
+	assert.Len(t, runProcessor(rp, 800), 800)
+
+	// Each series is applied by a single worker, in order.
+	applied := map[string]int{}
+	for i, w := range workers {
+		last := map[string]int{}
+		for _, m := range w.metrics {
+			if worker, ok := applied[m.Name()]; ok && worker != i {
+				t.Fatalf("series %s applied by workers %d and %d", m.Name(), worker, i)
+			}
+			applied[m.Name()] = i
+
+			value := int(m.Fields()["value"].(int64))
+			if prev, ok := last[m.Name()]; ok {
+				assert.True(t, value > prev, "%s reordered", m.Name())
+			}
+			last[m.Name()] = value
+		}
+	}
+	assert.Len(t, applied, 8)
+}
+
+func TestRunningProcessor_RunNoOrdering(t *testing.T) {
+	workers := []*recordingProcessor{{}, {}}
+	rp := NewRunningProcessor("test",
+		&ProcessorConfig{Workers: 2, Ordering: OrderingNone},
+		workers[0], workers[1],
+	)
+
+	assert.Len(t, runProcessor(rp, 100), 100)
+	assert.Equal(t, 100, len(workers[0].metrics)+len(workers[1].metrics))
+}
@@ -11,11 +11,14 @@ Usage:
 The commands & flags are:
 
   config              print out full sample configuration to stdout
+  config check        check the configuration, report problems such as
+                      unused keys, and exit non-zero if there are any
   version             print the version to stdout
 
   --config <file>     configuration file to load
   --test              gather metrics once, print them to stdout, and exit
   --config-directory  directory containing additional *.conf files
+  --watch-config      reload the config when the config file or directory change
   --input-filter      filter the input plugins to enable, separator is :
   --output-filter     filter the output plugins to enable, separator is :
   --usage             print usage for a plugin, ie, 'telegraf --usage mysql'
@@ -34,6 +37,9 @@ Examples:
   # generate config with only cpu input & influxdb output plugins defined
   telegraf --input-filter cpu --output-filter influxdb config
 
+  # check a config file and its config directory for problems
+  telegraf --config telegraf.conf --config-directory telegraf.d config check
+
   # run a single telegraf collection, outputing metrics to stdout
   telegraf --config telegraf.conf --test
 
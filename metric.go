@@ -65,4 +65,10 @@ type Metric interface {
 	// Mark Metric as an aggregate
 	SetAggregate(bool)
 	IsAggregate() bool
+
+	// Origin returns the alias of the input that produced the Metric, or its
+	// name if it has no alias. It is empty if the Metric was not produced by
+	// an input.
+	Origin() string
+	SetOrigin(origin string)
 }
@@ -17,6 +17,7 @@ type metric struct {
 
 	tp        telegraf.ValueType
 	aggregate bool
+	origin    string
 }
 
 func New(
@@ -215,6 +216,7 @@ func (m *metric) Copy() telegraf.Metric {
 		tm:        m.tm,
 		tp:        m.tp,
 		aggregate: m.aggregate,
+		origin:    m.origin,
 	}
 
 	for i, tag := range m.tags {
@@ -235,6 +237,14 @@ func (m *metric) IsAggregate() bool {
 	return m.aggregate
 }
 
+func (m *metric) Origin() string {
+	return m.origin
+}
+
+func (m *metric) SetOrigin(origin string) {
+	m.origin = origin
+}
+
 func (m *metric) HashID() uint64 {
 	h := fnv.New64a()
 	h.Write([]byte(m.name))
@@ -340,3 +340,11 @@ func TestCopyAggreate(t *testing.T) {
 	m2 := m1.Copy()
 	assert.True(t, m2.IsAggregate())
 }
+
+func TestCopyOrigin(t *testing.T) {
+	m1 := baseMetric()
+	assert.Equal(t, "", m1.Origin())
+	m1.SetOrigin("frontend")
+	m2 := m1.Copy()
+	assert.Equal(t, "frontend", m2.Origin())
+}
@@ -1,5 +1,7 @@
 package telegraf
 
+import "fmt"
+
 type Output interface {
 	// Connect to the Output
 	Connect() error
@@ -9,7 +11,9 @@ type Output interface {
 	Description() string
 	// SampleConfig returns the default configuration of the Output
 	SampleConfig() string
-	// Write takes in group of points to be written to the Output
+	// Write takes in group of points to be written to the Output.
+	// If only part of the group was written, a *PartialWriteError can be
+	// returned to report which points were accepted or rejected.
 	Write(metrics []Metric) error
 }
 
@@ -29,3 +33,24 @@ type ServiceOutput interface {
 	// Stop the "service" that will provide an Output
 	Stop()
 }
+
+// PartialWriteError is returned by Output.Write when only some of the metrics
+// in the group were written. Metrics are identified by their index in the
+// group passed to Write. Metrics that are neither accepted nor rejected are
+// retried on the next write.
+type PartialWriteError struct {
+	Err error
+
+	// MetricsAccept contains the indexes of the metrics that were written.
+	MetricsAccept []int
+
+	// MetricsReject contains the indexes of the metrics that were permanently
+	// rejected, for example because of an invalid field type. They are
+	// dropped and will not be retried.
+	MetricsReject []int
+}
+
+func (e *PartialWriteError) Error() string {
+	return fmt.Sprintf("partial write, %d accepted, %d rejected: %v",
+		len(e.MetricsAccept), len(e.MetricsReject), e.Err)
+}
@@ -0,0 +1,9 @@
+package telegraf
+
+// Initializer is an interface that plugins of any type can implement to be
+// initialized once their configuration is loaded.
+type Initializer interface {
+	// Init performs the setup of the plugin, returning an error if its
+	// configuration is invalid. It is called before the plugin is started.
+	Init() error
+}
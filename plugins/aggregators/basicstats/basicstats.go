@@ -236,6 +236,10 @@ func getConfiguredStats(m *BasicStats) *configuredStats {
 	return m.statsConfig
 }
 
+func (m *BasicStats) Expire(id uint64) {
+	delete(m.cache, id)
+}
+
 func (m *BasicStats) Reset() {
 	m.cache = make(map[uint64]aggregate)
 }
@@ -72,6 +72,12 @@ var sampleConfig = `
   ## aggregator and will not get sent to the output plugins.
   drop_original = false
 
+  ## Time after which the counts of a series not updated are removed, and
+  ## maximum number of series kept, the least recently updated ones being
+  ## removed first.
+  # series_expiry = "1h"
+  # max_series = 10000
+
   ## Example config that aggregates all fields of the metric.
   # [[aggregators.histogram.config]]
   #   ## The set of buckets.
@@ -205,6 +211,11 @@ func (h *HistogramAggregator) groupField(
 // small value, we will get a histogram with a small amount of the distribution.
 func (h *HistogramAggregator) Reset() {}
 
+// Expire removes the counts of the series
+func (h *HistogramAggregator) Expire(id uint64) {
+	delete(h.cache, id)
+}
+
 // resetCache resets cached counts(hits) in the buckets
 func (h *HistogramAggregator) resetCache() {
 	h.cache = make(map[uint64]metricHistogramCollection)
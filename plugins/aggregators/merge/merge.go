@@ -0,0 +1,135 @@
+package merge
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "10s"
+  ## Time to wait for the metrics with the same timestamp before emitting
+  ## the merged metric.
+  delay = "100ms"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = true
+`
+
+type Merge struct {
+	// groups holds the metrics being merged by series and timestamp, in the
+	// order they were added.
+	groups []*group
+	index  map[groupKey]*group
+}
+
+type groupKey struct {
+	id   uint64
+	time int64
+}
+
+type group struct {
+	key    groupKey
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	time   time.Time
+	tp     telegraf.ValueType
+}
+
+func NewMerge() *Merge {
+	m := &Merge{}
+	m.Reset()
+	return m
+}
+
+func (m *Merge) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *Merge) Description() string {
+	return "Merge the fields of the metrics of a series with the same timestamp into one metric."
+}
+
+func (m *Merge) Add(in telegraf.Metric) {
+	key := groupKey{id: in.HashID(), time: in.Time().UnixNano()}
+	g, ok := m.index[key]
+	if !ok {
+		g = &group{
+			key:    key,
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: make(map[string]interface{}, len(in.FieldList())),
+			time:   in.Time(),
+			tp:     in.Type(),
+		}
+		m.index[key] = g
+		m.groups = append(m.groups, g)
+	} else if g.tp != in.Type() {
+		g.tp = telegraf.Untyped
+	}
+
+	// Later values replace earlier ones of the same field.
+	for _, field := range in.FieldList() {
+		g.fields[field.Key] = field.Value
+	}
+}
+
+func (m *Merge) Push(acc telegraf.Accumulator) {
+	for _, g := range m.groups {
+		g.push(acc)
+	}
+}
+
+// PushUntil pushes the merged metrics with a time up to t and removes them,
+// so that they are emitted once the delay has elapsed rather than at the end
+// of the period.
+func (m *Merge) PushUntil(acc telegraf.Accumulator, t time.Time) time.Time {
+	var next time.Time
+	groups := m.groups[:0]
+	for _, g := range m.groups {
+		if g.time.After(t) {
+			groups = append(groups, g)
+			if next.IsZero() || g.time.Before(next) {
+				next = g.time
+			}
+			continue
+		}
+		g.push(acc)
+		delete(m.index, g.key)
+	}
+	for i := len(groups); i < len(m.groups); i++ {
+		m.groups[i] = nil
+	}
+	m.groups = groups
+	return next
+}
+
+func (m *Merge) Reset() {
+	m.groups = nil
+	m.index = make(map[groupKey]*group)
+}
+
+func (g *group) push(acc telegraf.Accumulator) {
+	switch g.tp {
+	case telegraf.Counter:
+		acc.AddCounter(g.name, g.fields, g.tags, g.time)
+	case telegraf.Gauge:
+		acc.AddGauge(g.name, g.fields, g.tags, g.time)
+	case telegraf.Summary:
+		acc.AddSummary(g.name, g.fields, g.tags, g.time)
+	case telegraf.Histogram:
+		acc.AddHistogram(g.name, g.fields, g.tags, g.time)
+	default:
+		acc.AddFields(g.name, g.fields, g.tags, g.time)
+	}
+}
+
+func init() {
+	aggregators.Add("merge", func() telegraf.Aggregator {
+		return NewMerge()
+	})
+}
@@ -0,0 +1,85 @@
+package merge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(
+	tags map[string]string,
+	fields map[string]interface{},
+	sec int64,
+) telegraf.Metric {
+	m, err := metric.New("container", tags, fields, time.Unix(sec, 0))
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestMerge(t *testing.T) {
+	m := NewMerge()
+	m.Add(newMetric(map[string]string{"id": "a"}, map[string]interface{}{"cpu": 1.0}, 0))
+	m.Add(newMetric(map[string]string{"id": "a"}, map[string]interface{}{"mem": int64(10)}, 0))
+	m.Add(newMetric(map[string]string{"id": "b"}, map[string]interface{}{"cpu": 2.0}, 0))
+	m.Add(newMetric(map[string]string{"id": "a"}, map[string]interface{}{"cpu": 3.0}, 10))
+	m.Add(newMetric(map[string]string{"id": "a"}, map[string]interface{}{"cpu": 4.0}, 10))
+
+	acc := testutil.Accumulator{}
+	m.Push(&acc)
+	require.Len(t, acc.Metrics, 3)
+
+	assert.Equal(t, map[string]string{"id": "a"}, acc.Metrics[0].Tags)
+	assert.Equal(t, map[string]interface{}{"cpu": 1.0, "mem": int64(10)}, acc.Metrics[0].Fields)
+	assert.Equal(t, time.Unix(0, 0), acc.Metrics[0].Time)
+
+	assert.Equal(t, map[string]string{"id": "b"}, acc.Metrics[1].Tags)
+	assert.Equal(t, map[string]interface{}{"cpu": 2.0}, acc.Metrics[1].Fields)
+
+	// Later values replace earlier ones.
+	assert.Equal(t, map[string]interface{}{"cpu": 4.0}, acc.Metrics[2].Fields)
+	assert.Equal(t, time.Unix(10, 0), acc.Metrics[2].Time)
+}
+
+func TestMergeReset(t *testing.T) {
+	m := NewMerge()
+	m.Add(newMetric(map[string]string{"id": "a"}, map[string]interface{}{"cpu": 1.0}, 0))
+	m.Reset()
+
+	acc := testutil.Accumulator{}
+	m.Push(&acc)
+	assert.Len(t, acc.Metrics, 0)
+}
+
+func TestMergePushUntil(t *testing.T) {
+	m := NewMerge()
+	m.Add(newMetric(map[string]string{"id": "a"}, map[string]interface{}{"cpu": 1.0}, 0))
+	m.Add(newMetric(map[string]string{"id": "a"}, map[string]interface{}{"cpu": 2.0}, 10))
+	m.Add(newMetric(map[string]string{"id": "a"}, map[string]interface{}{"mem": int64(10)}, 0))
+
+	acc := testutil.Accumulator{}
+	next := m.PushUntil(&acc, time.Unix(5, 0))
+	assert.Equal(t, time.Unix(10, 0), next)
+	require.Len(t, acc.Metrics, 1)
+	assert.Equal(t, map[string]interface{}{"cpu": 1.0, "mem": int64(10)}, acc.Metrics[0].Fields)
+	assert.Equal(t, time.Unix(0, 0), acc.Metrics[0].Time)
+
+	// A metric received after its group was pushed starts a new group.
+	m.Add(newMetric(map[string]string{"id": "a"}, map[string]interface{}{"mem": int64(20)}, 0))
+	acc.ClearMetrics()
+	next = m.PushUntil(&acc, time.Unix(10, 0))
+	assert.True(t, next.IsZero())
+	require.Len(t, acc.Metrics, 2)
+	assert.Equal(t, map[string]interface{}{"cpu": 2.0}, acc.Metrics[0].Fields)
+	assert.Equal(t, map[string]interface{}{"mem": int64(20)}, acc.Metrics[1].Fields)
+
+	acc.ClearMetrics()
+	m.Push(&acc)
+	assert.Len(t, acc.Metrics, 0)
+}
@@ -97,6 +97,10 @@ func (m *MinMax) Push(acc telegraf.Accumulator) {
 	}
 }
 
+func (m *MinMax) Expire(id uint64) {
+	delete(m.cache, id)
+}
+
 func (m *MinMax) Reset() {
 	m.cache = make(map[uint64]aggregate)
 }
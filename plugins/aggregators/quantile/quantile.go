@@ -0,0 +1,202 @@
+package quantile
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+// sketchSuffix is the suffix of the fields holding serialized sketches.
+const sketchSuffix = "_sketch"
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Quantiles to compute, in the range [0, 1]. The quantile 0.99 of the
+  ## field "latency" is emitted as "latency_p99".
+  # quantiles = [0.5, 0.9, 0.99, 0.999]
+
+  ## Relative accuracy of the quantiles, 0.01 meaning that the estimates are
+  ## within 1% of the actual values.
+  # relative_accuracy = 0.01
+
+  ## Maximum number of bins of the sketch of each field, bounding its memory.
+  ## When exceeded, the accuracy of the quantiles closest to zero degrades.
+  # max_bins = 2048
+
+  ## Emit the sketch of each field, serialized as a string field with the
+  ## suffix "_sketch". String fields with this suffix are merged in the
+  ## sketch of the field without the suffix, so that a downstream Telegraf
+  ## can compute the quantiles over several hosts.
+  # emit_sketch = false
+`
+
+type Quantile struct {
+	Quantiles        []float64
+	RelativeAccuracy float64 `toml:"relative_accuracy"`
+	MaxBins          int     `toml:"max_bins"`
+	EmitSketch       bool    `toml:"emit_sketch"`
+
+	cache map[uint64]aggregate
+}
+
+type aggregate struct {
+	name   string
+	tags   map[string]string
+	fields map[string]*sketch
+}
+
+func NewQuantile() *Quantile {
+	q := &Quantile{
+		Quantiles:        []float64{0.5, 0.9, 0.99, 0.999},
+		RelativeAccuracy: 0.01,
+		MaxBins:          2048,
+	}
+	q.Reset()
+	return q
+}
+
+func (q *Quantile) SampleConfig() string {
+	return sampleConfig
+}
+
+func (q *Quantile) Description() string {
+	return "Keep the quantiles of each field of the metrics passing through."
+}
+
+func (q *Quantile) Init() error {
+	for _, quantile := range q.Quantiles {
+		if quantile < 0 || quantile > 1 {
+			return fmt.Errorf("quantile %v is not in the range [0, 1]", quantile)
+		}
+	}
+	if q.RelativeAccuracy <= 0 || q.RelativeAccuracy >= 1 {
+		return fmt.Errorf("relative_accuracy %v is not in the range (0, 1)",
+			q.RelativeAccuracy)
+	}
+	return nil
+}
+
+func (q *Quantile) Add(in telegraf.Metric) {
+	id := in.HashID()
+	a, ok := q.cache[id]
+	if !ok {
+		a = aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: make(map[string]*sketch),
+		}
+		q.cache[id] = a
+	}
+
+	for _, field := range in.FieldList() {
+		if s, ok := field.Value.(string); ok && strings.HasSuffix(field.Key, sketchSuffix) {
+			q.merge(a, strings.TrimSuffix(field.Key, sketchSuffix), s)
+			continue
+		}
+		if v, ok := convert(field.Value); ok {
+			q.sketch(a, field.Key).add(v)
+		}
+	}
+}
+
+// merge merges the serialized sketch in the sketch of the field.
+func (q *Quantile) merge(a aggregate, field string, text string) {
+	received := newSketch(q.RelativeAccuracy, q.MaxBins)
+	err := received.UnmarshalText([]byte(text))
+	if err == nil {
+		err = q.sketch(a, field).merge(received)
+	}
+	if err != nil {
+		log.Printf("E! [aggregators.quantile] Unable to merge sketch of "+
+			"field %s of %s: %s", field, a.name, err)
+	}
+}
+
+// sketch returns the sketch of the field, creating it if needed.
+func (q *Quantile) sketch(a aggregate, field string) *sketch {
+	s, ok := a.fields[field]
+	if !ok {
+		s = newSketch(q.RelativeAccuracy, q.MaxBins)
+		a.fields[field] = s
+	}
+	return s
+}
+
+func (q *Quantile) Push(acc telegraf.Accumulator) {
+	for _, a := range q.cache {
+		fields := make(map[string]interface{})
+		for k, s := range a.fields {
+			if s.count == 0 {
+				continue
+			}
+			for _, quantile := range q.Quantiles {
+				fields[k+"_"+quantileName(quantile)] = s.quantile(quantile)
+			}
+			if q.EmitSketch {
+				text, err := s.MarshalText()
+				if err != nil {
+					log.Printf("E! [aggregators.quantile] Unable to serialize "+
+						"sketch of field %s of %s: %s", k, a.name, err)
+					continue
+				}
+				fields[k+sketchSuffix] = string(text)
+			}
+		}
+		if len(fields) > 0 {
+			acc.AddFields(a.name, fields, a.tags)
+		}
+	}
+}
+
+func (q *Quantile) Expire(id uint64) {
+	delete(q.cache, id)
+}
+
+func (q *Quantile) Reset() {
+	q.cache = make(map[uint64]aggregate)
+}
+
+// quantileName returns the name of the quantile q, made of its digits after
+// the decimal point, with at least two of them: p50 for 0.5, p999 for 0.999.
+func quantileName(q float64) string {
+	switch {
+	case q <= 0:
+		return "p0"
+	case q >= 1:
+		return "p100"
+	}
+	digits := strings.TrimPrefix(strconv.FormatFloat(q, 'f', -1, 64), "0.")
+	if len(digits) == 1 {
+		digits += "0"
+	}
+	return "p" + digits
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	aggregators.Add("quantile", func() telegraf.Aggregator {
+		return NewQuantile()
+	})
+}
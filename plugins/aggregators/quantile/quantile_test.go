@@ -0,0 +1,114 @@
+package quantile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(fields map[string]interface{}) telegraf.Metric {
+	m, err := metric.New("http",
+		map[string]string{"host": "localhost"},
+		fields,
+		time.Now(),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestQuantile(t *testing.T) {
+	q := NewQuantile()
+	q.Quantiles = []float64{0, 0.5, 0.9, 1}
+	require.NoError(t, q.Init())
+
+	for i := 1; i <= 100; i++ {
+		q.Add(newMetric(map[string]interface{}{
+			"latency": float64(i),
+			"size":    int64(i * 10),
+			"status":  "ok",
+		}))
+	}
+
+	acc := testutil.Accumulator{}
+	q.Push(&acc)
+	require.Len(t, acc.Metrics, 1)
+	fields := acc.Metrics[0].Fields
+	assert.Len(t, fields, 8)
+	assert.Equal(t, 1.0, fields["latency_p0"])
+	assert.InDelta(t, 50.0, fields["latency_p50"], 0.5)
+	assert.InDelta(t, 90.0, fields["latency_p90"], 0.9)
+	assert.Equal(t, 100.0, fields["latency_p100"])
+	assert.InDelta(t, 500.0, fields["size_p50"], 5)
+	assert.Equal(t, map[string]string{"host": "localhost"}, acc.Metrics[0].Tags)
+
+	q.Reset()
+	acc.ClearMetrics()
+	q.Push(&acc)
+	assert.Len(t, acc.Metrics, 0)
+}
+
+func TestQuantileMergeSketches(t *testing.T) {
+	var sketches []string
+	for k := 0; k < 2; k++ {
+		q := NewQuantile()
+		q.Quantiles = []float64{0.5}
+		q.EmitSketch = true
+		require.NoError(t, q.Init())
+		for i := 1; i <= 50; i++ {
+			q.Add(newMetric(map[string]interface{}{"latency": float64(k*50 + i)}))
+		}
+
+		acc := testutil.Accumulator{}
+		q.Push(&acc)
+		require.Len(t, acc.Metrics, 1)
+		sketch, ok := acc.Metrics[0].Fields["latency_sketch"].(string)
+		require.True(t, ok)
+		sketches = append(sketches, sketch)
+	}
+
+	q := NewQuantile()
+	q.Quantiles = []float64{0.5, 1}
+	require.NoError(t, q.Init())
+	for _, sketch := range sketches {
+		q.Add(newMetric(map[string]interface{}{"latency_sketch": sketch}))
+	}
+	q.Add(newMetric(map[string]interface{}{"latency_sketch": "invalid"}))
+
+	acc := testutil.Accumulator{}
+	q.Push(&acc)
+	require.Len(t, acc.Metrics, 1)
+	assert.InDelta(t, 50.0, acc.Metrics[0].Fields["latency_p50"], 0.5)
+	assert.Equal(t, 100.0, acc.Metrics[0].Fields["latency_p100"])
+}
+
+func TestQuantileInit(t *testing.T) {
+	q := NewQuantile()
+	q.Quantiles = []float64{1.5}
+	assert.Error(t, q.Init())
+
+	q = NewQuantile()
+	q.RelativeAccuracy = 0
+	assert.Error(t, q.Init())
+}
+
+func TestQuantileName(t *testing.T) {
+	for q, expected := range map[float64]string{
+		0:     "p0",
+		0.05:  "p05",
+		0.5:   "p50",
+		0.9:   "p90",
+		0.95:  "p95",
+		0.99:  "p99",
+		0.999: "p999",
+		1:     "p100",
+	} {
+		assert.Equal(t, expected, quantileName(q))
+	}
+}
@@ -0,0 +1,276 @@
+package quantile
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// sketchVersion is the version of the serialization format of sketches.
+const sketchVersion = 1
+
+// minIndexable is the smallest magnitude of the values counted in bins,
+// smaller values are counted as zero.
+const minIndexable = 1e-9
+
+// sketch is a DDSketch, estimating quantiles with a relative accuracy
+// guarantee. Values are counted in bins of exponentially growing width, the
+// value of the bin of index i being about gamma^i. When there are more than
+// maxBins bins for positive or negative values, the bins of the smallest
+// magnitude are collapsed, trading the accuracy of the quantiles closest to
+// zero for bounded memory.
+//
+// Sketches with the same accuracy are merged by adding the counts of their
+// bins, giving the same result as a single sketch of all the values.
+type sketch struct {
+	alpha   float64
+	gamma   float64
+	logG    float64
+	maxBins int
+
+	positive map[int32]uint64
+	negative map[int32]uint64
+	zero     uint64
+	count    uint64
+	min, max float64
+}
+
+func newSketch(alpha float64, maxBins int) *sketch {
+	gamma := (1 + alpha) / (1 - alpha)
+	return &sketch{
+		alpha:    alpha,
+		gamma:    gamma,
+		logG:     math.Log(gamma),
+		maxBins:  maxBins,
+		positive: make(map[int32]uint64),
+		negative: make(map[int32]uint64),
+		min:      math.Inf(1),
+		max:      math.Inf(-1),
+	}
+}
+
+// index returns the index of the bin of the positive value v.
+func (s *sketch) index(v float64) int32 {
+	return int32(math.Ceil(math.Log(v) / s.logG))
+}
+
+// value returns the estimate of the values in the bin of index i, which is
+// within the relative accuracy of all of them.
+func (s *sketch) value(i int32) float64 {
+	return 2 * math.Pow(s.gamma, float64(i)) / (s.gamma + 1)
+}
+
+func (s *sketch) add(v float64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+	switch {
+	case v > minIndexable:
+		s.positive[s.index(v)]++
+		s.collapse(s.positive)
+	case v < -minIndexable:
+		s.negative[s.index(-v)]++
+		s.collapse(s.negative)
+	default:
+		s.zero++
+	}
+	s.count++
+	s.min = math.Min(s.min, v)
+	s.max = math.Max(s.max, v)
+}
+
+// merge adds the values of o to s. Both sketches must have the same
+// accuracy.
+func (s *sketch) merge(o *sketch) error {
+	if o.alpha != s.alpha {
+		return fmt.Errorf("cannot merge sketch of relative accuracy %v into "+
+			"sketch of relative accuracy %v", o.alpha, s.alpha)
+	}
+	for i, n := range o.positive {
+		s.positive[i] += n
+	}
+	for i, n := range o.negative {
+		s.negative[i] += n
+	}
+	s.collapse(s.positive)
+	s.collapse(s.negative)
+	s.zero += o.zero
+	s.count += o.count
+	s.min = math.Min(s.min, o.min)
+	s.max = math.Max(s.max, o.max)
+	return nil
+}
+
+// collapse merges the bins of the smallest indexes of bins into a single one
+// until there are at most maxBins.
+func (s *sketch) collapse(bins map[int32]uint64) {
+	if s.maxBins <= 0 || len(bins) <= s.maxBins {
+		return
+	}
+	indexes := sortedIndexes(bins)
+	excess := len(indexes) - s.maxBins
+	target := indexes[excess]
+	for _, i := range indexes[:excess] {
+		bins[target] += bins[i]
+		delete(bins, i)
+	}
+}
+
+// quantile returns the estimate of the q-quantile of the values, q being in
+// the range [0, 1].
+func (s *sketch) quantile(q float64) float64 {
+	if s.count == 0 {
+		return math.NaN()
+	}
+	if q <= 0 {
+		return s.min
+	}
+	if q >= 1 {
+		return s.max
+	}
+
+	rank := uint64(q * float64(s.count-1))
+	var n uint64
+	estimate := s.max
+	indexes := sortedIndexes(s.negative)
+	for k := len(indexes) - 1; k >= 0; k-- {
+		n += s.negative[indexes[k]]
+		if n > rank {
+			estimate = -s.value(indexes[k])
+			break
+		}
+	}
+	if n <= rank {
+		n += s.zero
+		if n > rank {
+			estimate = 0
+		}
+	}
+	if n <= rank {
+		for _, i := range sortedIndexes(s.positive) {
+			n += s.positive[i]
+			if n > rank {
+				estimate = s.value(i)
+				break
+			}
+		}
+	}
+	return math.Max(s.min, math.Min(s.max, estimate))
+}
+
+// MarshalText encodes the sketch in base64, for emitting it as a string
+// field.
+func (s *sketch) MarshalText() ([]byte, error) {
+	var buf bytes.Buffer
+	tmp := make([]byte, binary.MaxVarintLen64)
+	putUvarint := func(v uint64) {
+		buf.Write(tmp[:binary.PutUvarint(tmp, v)])
+	}
+	putVarint := func(v int64) {
+		buf.Write(tmp[:binary.PutVarint(tmp, v)])
+	}
+	putFloat := func(v float64) {
+		binary.LittleEndian.PutUint64(tmp, math.Float64bits(v))
+		buf.Write(tmp[:8])
+	}
+	putBins := func(bins map[int32]uint64) {
+		putUvarint(uint64(len(bins)))
+		var prev int32
+		for _, i := range sortedIndexes(bins) {
+			putVarint(int64(i - prev))
+			putUvarint(bins[i])
+			prev = i
+		}
+	}
+
+	buf.WriteByte(sketchVersion)
+	putFloat(s.alpha)
+	putFloat(s.min)
+	putFloat(s.max)
+	putUvarint(s.zero)
+	putBins(s.positive)
+	putBins(s.negative)
+
+	text := make([]byte, base64.StdEncoding.EncodedLen(buf.Len()))
+	base64.StdEncoding.Encode(text, buf.Bytes())
+	return text, nil
+}
+
+// UnmarshalText decodes a sketch encoded by MarshalText, keeping the maximum
+// number of bins of s.
+func (s *sketch) UnmarshalText(text []byte) error {
+	data := make([]byte, base64.StdEncoding.DecodedLen(len(text)))
+	n, err := base64.StdEncoding.Decode(data, text)
+	if err != nil {
+		return err
+	}
+	r := bytes.NewReader(data[:n])
+
+	version, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if version != sketchVersion {
+		return fmt.Errorf("unsupported sketch version %d", version)
+	}
+
+	var alpha, min, max float64
+	for _, v := range []*float64{&alpha, &min, &max} {
+		var bits uint64
+		if err := binary.Read(r, binary.LittleEndian, &bits); err != nil {
+			return err
+		}
+		*v = math.Float64frombits(bits)
+	}
+	if !(alpha > 0 && alpha < 1) {
+		return fmt.Errorf("invalid relative accuracy %v", alpha)
+	}
+
+	*s = *newSketch(alpha, s.maxBins)
+	s.min, s.max = min, max
+	if s.zero, err = binary.ReadUvarint(r); err != nil {
+		return err
+	}
+	s.count = s.zero
+	for _, bins := range []map[int32]uint64{s.positive, s.negative} {
+		size, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		var i int64
+		for k := uint64(0); k < size; k++ {
+			delta, err := binary.ReadVarint(r)
+			if err != nil {
+				return err
+			}
+			count, err := binary.ReadUvarint(r)
+			if err != nil {
+				return err
+			}
+			i += delta
+			if i < math.MinInt32 || i > math.MaxInt32 {
+				return errors.New("bin index out of range")
+			}
+			bins[int32(i)] += count
+			s.count += count
+		}
+		s.collapse(bins)
+	}
+	if r.Len() != 0 {
+		return errors.New("trailing data")
+	}
+	return nil
+}
+
+func sortedIndexes(bins map[int32]uint64) []int32 {
+	indexes := make([]int32, 0, len(bins))
+	for i := range bins {
+		indexes = append(indexes, i)
+	}
+	sort.Slice(indexes, func(a, b int) bool { return indexes[a] < indexes[b] })
+	return indexes
+}
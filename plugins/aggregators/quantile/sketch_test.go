@@ -0,0 +1,105 @@
+package quantile
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exactQuantile returns the q-quantile of the sorted values, using the same
+// rank as the sketch.
+func exactQuantile(sorted []float64, q float64) float64 {
+	return sorted[int(q*float64(len(sorted)-1))]
+}
+
+func assertAccurate(t *testing.T, s *sketch, values []float64) {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	for _, q := range []float64{0, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99, 0.999, 1} {
+		expected := exactQuantile(sorted, q)
+		actual := s.quantile(q)
+		assert.InDelta(t, expected, actual, math.Abs(expected)*s.alpha+1e-9,
+			"quantile %v", q)
+	}
+}
+
+func TestSketchAccuracy(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var values []float64
+	s := newSketch(0.01, 2048)
+	for i := 0; i < 10000; i++ {
+		v := math.Exp(r.NormFloat64() * 3)
+		if i%3 == 0 {
+			v = -v
+		}
+		if i%100 == 0 {
+			v = 0
+		}
+		values = append(values, v)
+		s.add(v)
+	}
+	assert.Equal(t, uint64(len(values)), s.count)
+	assertAccurate(t, s, values)
+}
+
+func TestSketchEmpty(t *testing.T) {
+	s := newSketch(0.01, 2048)
+	assert.True(t, math.IsNaN(s.quantile(0.5)))
+}
+
+func TestSketchMerge(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var values []float64
+	merged := newSketch(0.01, 2048)
+	for k := 0; k < 3; k++ {
+		s := newSketch(0.01, 2048)
+		for i := 0; i < 1000; i++ {
+			v := r.Float64() * float64(1000*(k+1))
+			values = append(values, v)
+			s.add(v)
+		}
+		require.NoError(t, merged.merge(s))
+	}
+	assert.Equal(t, uint64(len(values)), merged.count)
+	assertAccurate(t, merged, values)
+
+	assert.Error(t, merged.merge(newSketch(0.02, 2048)))
+}
+
+func TestSketchCollapse(t *testing.T) {
+	s := newSketch(0.01, 100)
+	var values []float64
+	for i := 1; i <= 100000; i++ {
+		values = append(values, float64(i))
+		s.add(float64(i))
+	}
+	assert.Len(t, s.positive, 100)
+
+	// The highest quantiles keep their accuracy.
+	sorted := values
+	for _, q := range []float64{0.99, 0.999, 1} {
+		expected := exactQuantile(sorted, q)
+		assert.InDelta(t, expected, s.quantile(q), expected*s.alpha)
+	}
+}
+
+func TestSketchMarshal(t *testing.T) {
+	s := newSketch(0.01, 2048)
+	for _, v := range []float64{-100, -1.5, 0, 0, 1, 2.5, 1000, 1e9} {
+		s.add(v)
+	}
+	text, err := s.MarshalText()
+	require.NoError(t, err)
+
+	decoded := newSketch(0.05, 2048)
+	require.NoError(t, decoded.UnmarshalText(text))
+	assert.Equal(t, s, decoded)
+
+	for _, text := range []string{"", "not base64!", "AgAAAA==", string(text[:len(text)-4])} {
+		assert.Error(t, decoded.UnmarshalText([]byte(text)), text)
+	}
+}
@@ -354,7 +354,7 @@ func (a *AMQPConsumer) process(msgs <-chan amqp.Delivery, acc telegraf.Accumulat
 			log.Printf("E! %v: error parsing metric - %v", err, string(d.Body))
 		} else {
 			for _, m := range metrics {
-				acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+				acc.AddMetric(m)
 			}
 		}
 
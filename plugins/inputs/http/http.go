@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -85,6 +86,12 @@ func (*HTTP) Description() string {
 // Gather takes in an accumulator and adds the metrics that the Input
 // gathers. This is called every "interval"
 func (h *HTTP) Gather(acc telegraf.Accumulator) error {
+	return h.GatherContext(context.Background(), acc)
+}
+
+// GatherContext gathers like Gather, aborting the requests in progress when
+// ctx is done.
+func (h *HTTP) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
 	if h.parser == nil {
 		return errors.New("Parser is not set")
 	}
@@ -108,7 +115,7 @@ func (h *HTTP) Gather(acc telegraf.Accumulator) error {
 		wg.Add(1)
 		go func(url string) {
 			defer wg.Done()
-			if err := h.gatherURL(acc, url); err != nil {
+			if err := h.gatherURL(ctx, acc, url); err != nil {
 				acc.AddError(fmt.Errorf("[url=%s]: %s", url, err))
 			}
 		}(u)
@@ -126,12 +133,14 @@ func (h *HTTP) SetParser(parser parsers.Parser) {
 
 // Gathers data from a particular URL
 // Parameters:
+//     ctx    : The context of the request
 //     acc    : The telegraf Accumulator to use
 //     url    : endpoint to send request to
 //
 // Returns:
 //     error: Any error that may have occurred
 func (h *HTTP) gatherURL(
+	ctx context.Context,
 	acc telegraf.Accumulator,
 	url string,
 ) error {
@@ -139,6 +148,7 @@ func (h *HTTP) gatherURL(
 	if err != nil {
 		return err
 	}
+	request = request.WithContext(ctx)
 
 	for k, v := range h.Headers {
 		if strings.ToLower(k) == "host" {
@@ -180,7 +190,7 @@ func (h *HTTP) gatherURL(
 		if !metric.HasTag("url") {
 			metric.AddTag("url", url)
 		}
-		acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+		acc.AddMetric(metric)
 	}
 
 	return nil
@@ -1,9 +1,11 @@
 package http_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	plugin "github.com/influxdata/telegraf/plugins/inputs/http"
 	"github.com/influxdata/telegraf/plugins/parsers"
@@ -90,6 +92,27 @@ func TestInvalidStatusCode(t *testing.T) {
 	require.Error(t, acc.GatherError(plugin.Gather))
 }
 
+func TestGatherCanceled(t *testing.T) {
+	release := make(chan struct{})
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer fakeServer.Close()
+	defer close(release)
+
+	plugin := &plugin.HTTP{
+		URLs: []string{fakeServer.URL + "/endpoint"},
+	}
+	p, _ := parsers.NewJSONParser("metricName", nil, nil)
+	plugin.SetParser(p)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.GatherContext(ctx, &acc))
+	require.Len(t, acc.Errors, 1)
+}
+
 func TestMethod(t *testing.T) {
 	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "POST" {
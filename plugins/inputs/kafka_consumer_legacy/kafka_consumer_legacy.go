@@ -148,7 +148,7 @@ func (k *Kafka) receiver() {
 						string(msg.Value), err.Error()))
 				}
 				for _, metric := range metrics {
-					k.acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+					k.acc.AddMetric(metric)
 				}
 			}
 
@@ -16,7 +16,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/inputs"
 
 	// Parsers
-	"github.com/influxdata/telegraf/plugins/inputs/logparser/grok"
+	"github.com/influxdata/telegraf/plugins/parsers/grok"
 )
 
 const (
@@ -3,13 +3,13 @@ package logparser
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
-	"strings"
 	"testing"
 
 	"github.com/influxdata/telegraf/testutil"
 
-	"github.com/influxdata/telegraf/plugins/inputs/logparser/grok"
+	"github.com/influxdata/telegraf/plugins/parsers/grok"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -17,7 +17,7 @@ import (
 func TestStartNoParsers(t *testing.T) {
 	logparser := &LogParserPlugin{
 		FromBeginning: true,
-		Files:         []string{"grok/testdata/*.log"},
+		Files:         []string{getTestdataDir() + "*.log"},
 	}
 
 	acc := testutil.Accumulator{}
@@ -25,15 +25,15 @@ func TestStartNoParsers(t *testing.T) {
 }
 
 func TestGrokParseLogFilesNonExistPattern(t *testing.T) {
-	thisdir := getCurrentDir()
+	testdata := getTestdataDir()
 	p := &grok.Parser{
 		Patterns:           []string{"%{FOOBAR}"},
-		CustomPatternFiles: []string{thisdir + "grok/testdata/test-patterns"},
+		CustomPatternFiles: []string{testdata + "test-patterns"},
 	}
 
 	logparser := &LogParserPlugin{
 		FromBeginning: true,
-		Files:         []string{thisdir + "grok/testdata/*.log"},
+		Files:         []string{testdata + "*.log"},
 		GrokParser:    p,
 	}
 
@@ -43,15 +43,15 @@ func TestGrokParseLogFilesNonExistPattern(t *testing.T) {
 }
 
 func TestGrokParseLogFiles(t *testing.T) {
-	thisdir := getCurrentDir()
+	testdata := getTestdataDir()
 	p := &grok.Parser{
 		Patterns:           []string{"%{TEST_LOG_A}", "%{TEST_LOG_B}"},
-		CustomPatternFiles: []string{thisdir + "grok/testdata/test-patterns"},
+		CustomPatternFiles: []string{testdata + "test-patterns"},
 	}
 
 	logparser := &LogParserPlugin{
 		FromBeginning: true,
-		Files:         []string{thisdir + "grok/testdata/*.log"},
+		Files:         []string{testdata + "*.log"},
 		GrokParser:    p,
 	}
 
@@ -71,7 +71,7 @@ func TestGrokParseLogFiles(t *testing.T) {
 		},
 		map[string]string{
 			"response_code": "200",
-			"path":          thisdir + "grok/testdata/test_a.log",
+			"path":          testdata + "test_a.log",
 		})
 
 	acc.AssertContainsTaggedFields(t, "logparser_grok",
@@ -81,7 +81,7 @@ func TestGrokParseLogFiles(t *testing.T) {
 			"nomodifier": "nomodifier",
 		},
 		map[string]string{
-			"path": thisdir + "grok/testdata/test_b.log",
+			"path": testdata + "test_b.log",
 		})
 }
 
@@ -90,10 +90,10 @@ func TestGrokParseLogFilesAppearLater(t *testing.T) {
 	defer os.RemoveAll(emptydir)
 	assert.NoError(t, err)
 
-	thisdir := getCurrentDir()
+	testdata := getTestdataDir()
 	p := &grok.Parser{
 		Patterns:           []string{"%{TEST_LOG_A}", "%{TEST_LOG_B}"},
-		CustomPatternFiles: []string{thisdir + "grok/testdata/test-patterns"},
+		CustomPatternFiles: []string{testdata + "test-patterns"},
 	}
 
 	logparser := &LogParserPlugin{
@@ -107,7 +107,7 @@ func TestGrokParseLogFilesAppearLater(t *testing.T) {
 
 	assert.Equal(t, acc.NFields(), 0)
 
-	_ = os.Symlink(thisdir+"grok/testdata/test_a.log", emptydir+"/test_a.log")
+	_ = os.Symlink(testdata+"test_a.log", emptydir+"/test_a.log")
 	assert.NoError(t, acc.GatherError(logparser.Gather))
 	acc.Wait(1)
 
@@ -129,16 +129,16 @@ func TestGrokParseLogFilesAppearLater(t *testing.T) {
 // Test that test_a.log line gets parsed even though we don't have the correct
 // pattern available for test_b.log
 func TestGrokParseLogFilesOneBad(t *testing.T) {
-	thisdir := getCurrentDir()
+	testdata := getTestdataDir()
 	p := &grok.Parser{
 		Patterns:           []string{"%{TEST_LOG_A}", "%{TEST_LOG_BAD}"},
-		CustomPatternFiles: []string{thisdir + "grok/testdata/test-patterns"},
+		CustomPatternFiles: []string{testdata + "test-patterns"},
 	}
 	assert.NoError(t, p.Compile())
 
 	logparser := &LogParserPlugin{
 		FromBeginning: true,
-		Files:         []string{thisdir + "grok/testdata/test_a.log"},
+		Files:         []string{testdata + "test_a.log"},
 		GrokParser:    p,
 	}
 
@@ -158,11 +158,14 @@ func TestGrokParseLogFilesOneBad(t *testing.T) {
 		},
 		map[string]string{
 			"response_code": "200",
-			"path":          thisdir + "grok/testdata/test_a.log",
+			"path":          testdata + "test_a.log",
 		})
 }
 
-func getCurrentDir() string {
+// getTestdataDir returns the testdata directory of the grok parser, whose
+// patterns and logs are shared with these tests.
+func getTestdataDir() string {
 	_, filename, _, _ := runtime.Caller(1)
-	return strings.Replace(filename, "logparser_test.go", "", 1)
+	dir := filepath.Dir(filename)
+	return filepath.Join(dir, "..", "..", "parsers", "grok", "testdata") + "/"
 }
@@ -72,7 +72,7 @@ func (n *NSQConsumer) Start(acc telegraf.Accumulator) error {
 			return nil
 		}
 		for _, metric := range metrics {
-			n.acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+			n.acc.AddMetric(metric)
 		}
 		message.Finish()
 		return nil
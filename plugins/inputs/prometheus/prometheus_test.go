@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -51,6 +52,13 @@ func TestPrometheusGeneratesMetrics(t *testing.T) {
 	assert.True(t, acc.HasTimestamp("test_metric", time.Unix(1490802350, 0)))
 	assert.False(t, acc.HasTag("test_metric", "address"))
 	assert.True(t, acc.TagValue("test_metric", "url") == ts.URL)
+
+	m, ok := acc.Get("go_goroutines")
+	require.True(t, ok)
+	assert.Equal(t, telegraf.Gauge, m.Type)
+	m, ok = acc.Get("go_gc_duration_seconds")
+	require.True(t, ok)
+	assert.Equal(t, telegraf.Summary, m.Type)
 }
 
 func TestPrometheusGeneratesMetricsWithHostNameTag(t *testing.T) {
@@ -19,6 +19,7 @@ import (
 	tlsint "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/prometheus"
 )
 
 type setReadBufferer interface {
@@ -120,7 +121,7 @@ func (ssl *streamSocketListener) read(c net.Conn) {
 			continue
 		}
 		for _, m := range metrics {
-			ssl.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+			ssl.AddMetric(m)
 		}
 	}
 
@@ -156,7 +157,7 @@ func (psl *packetSocketListener) listen() {
 			continue
 		}
 		for _, m := range metrics {
-			psl.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+			psl.AddMetric(m)
 		}
 	}
 }
@@ -225,6 +226,7 @@ func (sl *SocketListener) SampleConfig() string {
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  ## Stream sockets are parsed line by line and don't support "prometheus".
   # data_format = "influx"
 `
 }
@@ -253,6 +255,11 @@ func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 
 	switch spl[0] {
 	case "tcp", "tcp4", "tcp6", "unix", "unixpacket":
+		// stream sockets are parsed line by line
+		if _, ok := sl.Parser.(*prometheus.Parser); ok {
+			return fmt.Errorf("the prometheus data format is not supported on %s sockets", spl[0])
+		}
+
 		var (
 			err error
 			l   net.Listener
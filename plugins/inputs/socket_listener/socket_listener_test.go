@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf/plugins/parsers/prometheus"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -94,6 +95,16 @@ func TestSocketListener_tcp(t *testing.T) {
 	testSocketListener(t, sl, client)
 }
 
+func TestSocketListener_tcpPrometheus(t *testing.T) {
+	sl := newSocketListener()
+	sl.ServiceAddress = "tcp://127.0.0.1:0"
+	sl.SetParser(&prometheus.Parser{})
+
+	acc := &testutil.Accumulator{}
+	err := sl.Start(acc)
+	assert.Error(t, err)
+}
+
 func TestSocketListener_udp(t *testing.T) {
 	defer testEmptyLog(t)()
 
@@ -1,6 +1,7 @@
 package sqlserver
 
 import (
+	"context"
 	"database/sql"
 	"sync"
 	"time"
@@ -123,6 +124,12 @@ func initQueries(s *SQLServer) {
 
 // Gather collect data from SQL Server
 func (s *SQLServer) Gather(acc telegraf.Accumulator) error {
+	return s.GatherContext(context.Background(), acc)
+}
+
+// GatherContext collects data from SQL Server, aborting the queries in
+// progress when ctx is done
+func (s *SQLServer) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
 	if !isInitialized {
 		initQueries(s)
 	}
@@ -138,7 +145,7 @@ func (s *SQLServer) Gather(acc telegraf.Accumulator) error {
 			wg.Add(1)
 			go func(serv string, query Query) {
 				defer wg.Done()
-				acc.AddError(s.gatherServer(serv, query, acc))
+				acc.AddError(s.gatherServer(ctx, serv, query, acc))
 			}(serv, query)
 		}
 	}
@@ -147,14 +154,14 @@ func (s *SQLServer) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
-func (s *SQLServer) gatherServer(server string, query Query, acc telegraf.Accumulator) error {
+func (s *SQLServer) gatherServer(ctx context.Context, server string, query Query, acc telegraf.Accumulator) error {
 	// deferred opening
 	conn, err := sql.Open("mssql", server)
 	if err != nil {
 		return err
 	}
 	// verify that a connection can be made before making a query
-	err = conn.Ping()
+	err = conn.PingContext(ctx)
 	if err != nil {
 		// Handle error
 		return err
@@ -162,7 +169,7 @@ func (s *SQLServer) gatherServer(server string, query Query, acc telegraf.Accumu
 	defer conn.Close()
 
 	// execute query
-	rows, err := conn.Query(query.Script)
+	rows, err := conn.QueryContext(ctx, query.Script)
 	if err != nil {
 		return err
 	}
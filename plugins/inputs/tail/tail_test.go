@@ -112,6 +112,40 @@ func TestTailBadLine(t *testing.T) {
 	assert.Contains(t, acc.Errors[0].Error(), "E! Malformed log line")
 }
 
+// Test that the lines not matching the grok patterns are skipped.
+func TestTailGrokNoMatch(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.WriteString("no match\nvalue=42\n")
+	require.NoError(t, err)
+
+	tt := NewTail()
+	tt.FromBeginning = true
+	tt.Files = []string{tmpfile.Name()}
+	p, err := parsers.NewParser(&parsers.Config{
+		DataFormat:   "grok",
+		MetricName:   "tail_grok",
+		GrokPatterns: []string{"value=%{NUMBER:value:int}"},
+	})
+	require.NoError(t, err)
+	tt.SetParser(p)
+	defer tt.Stop()
+	defer tmpfile.Close()
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	require.NoError(t, acc.GatherError(tt.Gather))
+
+	acc.Wait(1)
+	acc.AssertContainsFields(t, "tail_grok",
+		map[string]interface{}{
+			"value": int64(42),
+		})
+	assert.Len(t, acc.Metrics, 1)
+	assert.Empty(t, acc.Errors)
+}
+
 func TestTailDosLineendings(t *testing.T) {
 	tmpfile, err := ioutil.TempFile("", "")
 	require.NoError(t, err)
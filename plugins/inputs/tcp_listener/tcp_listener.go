@@ -263,7 +263,7 @@ func (t *TcpListener) tcpParser() error {
 			metrics, err = t.parser.Parse(packet)
 			if err == nil {
 				for _, m := range metrics {
-					t.acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+					t.acc.AddMetric(m)
 				}
 			} else {
 				t.malformed++
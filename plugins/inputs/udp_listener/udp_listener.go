@@ -203,7 +203,7 @@ func (u *UdpListener) udpParser() error {
 			metrics, err = u.parser.Parse(packet)
 			if err == nil {
 				for _, m := range metrics {
-					u.acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+					u.acc.AddMetric(m)
 				}
 			} else {
 				u.malformed++
@@ -3,7 +3,9 @@ package file
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/outputs"
@@ -11,10 +13,13 @@ import (
 )
 
 type File struct {
-	Files []string
+	Files     []string
+	Overwrite bool
 
 	writers []io.Writer
 	closers []io.Closer
+	// paths are the files replaced on each write with overwrite.
+	paths []string
 
 	serializer serializers.Serializer
 }
@@ -23,6 +28,13 @@ var sampleConfig = `
   ## Files to write to, "stdout" is a specially handled file.
   files = ["stdout", "/tmp/metrics.out"]
 
+  ## Replace the content of the files with the metrics of each write instead
+  ## of appending them. The metrics are serialized together, as a batch.
+  ## A flush writes batches of metric_batch_size metrics and only the last
+  ## one is kept, so the metric_batch_size of the agent must exceed the
+  ## number of metrics of a flush interval for the files to hold all of them.
+  # overwrite = false
+
   ## Data format to output.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -42,6 +54,8 @@ func (f *File) Connect() error {
 	for _, file := range f.Files {
 		if file == "stdout" {
 			f.writers = append(f.writers, os.Stdout)
+		} else if f.Overwrite {
+			f.paths = append(f.paths, file)
 		} else {
 			var of *os.File
 			var err error
@@ -83,6 +97,10 @@ func (f *File) Description() string {
 }
 
 func (f *File) Write(metrics []telegraf.Metric) error {
+	if f.Overwrite {
+		return f.overwrite(metrics)
+	}
+
 	var writeErr error = nil
 	for _, metric := range metrics {
 		b, err := f.serializer.Serialize(metric)
@@ -100,6 +118,49 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 	return writeErr
 }
 
+// overwrite serializes the metrics as a batch and replaces the content of the
+// files with it.
+func (f *File) overwrite(metrics []telegraf.Metric) error {
+	b, err := f.serializer.SerializeBatch(metrics)
+	if err != nil {
+		return fmt.Errorf("failed to serialize message: %s", err)
+	}
+
+	var writeErr error
+	for _, writer := range f.writers {
+		writer.Write(b)
+	}
+	for _, path := range f.paths {
+		if err := replaceFile(path, b); err != nil {
+			writeErr = fmt.Errorf("E! failed to write file %s: %s", path, err)
+		}
+	}
+	return writeErr
+}
+
+// replaceFile replaces the content of the file with b. It is written to a
+// temporary file renamed over the file, so that readers never see it
+// partially written.
+func replaceFile(path string, b []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
 func init() {
 	outputs.Add("file", func() telegraf.Output {
 		return &File{}
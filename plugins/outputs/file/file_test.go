@@ -6,10 +6,13 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -180,6 +183,39 @@ func TestFileStdout(t *testing.T) {
 	assert.Equal(t, expNewFile, out)
 }
 
+func TestFileOverwrite(t *testing.T) {
+	fh := createFile()
+	defer os.Remove(fh.Name())
+	s, _ := serializers.NewPrometheusSerializer(true, false)
+	f := File{
+		Files:      []string{fh.Name()},
+		Overwrite:  true,
+		serializer: s,
+	}
+
+	err := f.Connect()
+	assert.NoError(t, err)
+
+	// Each write replaces the file, with a single block per metric family.
+	m1, _ := metric.New("load", map[string]string{"host": "a"},
+		map[string]interface{}{"value": 1.0}, time.Unix(0, 0))
+	m2, _ := metric.New("load", map[string]string{"host": "b"},
+		map[string]interface{}{"value": 2.0}, time.Unix(0, 0))
+	err = f.Write([]telegraf.Metric{m1})
+	assert.NoError(t, err)
+	err = f.Write([]telegraf.Metric{m1, m2})
+	assert.NoError(t, err)
+
+	validateFile(fh.Name(), `# HELP load Telegraf collected metric
+# TYPE load untyped
+load{host="a"} 1
+load{host="b"} 2
+`, t)
+
+	err = f.Close()
+	assert.NoError(t, err)
+}
+
 func createFile() *os.File {
 	f, err := ioutil.TempFile("", "")
 	if err != nil {
@@ -12,6 +12,8 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -39,6 +41,12 @@ const (
 )
 
 var (
+	// fieldTypeConflictRe matches the point of a field type conflict error.
+	fieldTypeConflictRe = regexp.MustCompile(
+		`input field "([^"]*)" on measurement "([^"]*)" is type (\w+)`)
+
+	// droppedRe matches the number of points dropped by a partial write.
+	droppedRe = regexp.MustCompile(`dropped=(\d+)`)
 
 	// Escape an identifier in InfluxQL.
 	escapeIdentifier = strings.NewReplacer(
@@ -317,13 +325,19 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 		return nil
 	}
 
+	apiErr := &APIError{
+		StatusCode:  resp.StatusCode,
+		Title:       resp.Status,
+		Description: desc,
+	}
+
 	// Other partial write errors, such as "field type conflict", are not
 	// correctable at this point and so the point is dropped instead of
 	// retrying.
 	if strings.Contains(desc, errStringPartialWrite) {
 		log.Printf("E! [outputs.influxdb]: when writing to [%s]: received error %v; discarding points",
 			c.URL(), desc)
-		return nil
+		return c.partialWriteError(metrics, apiErr)
 	}
 
 	// This error indicates a bug in either Telegraf line protocol
@@ -331,14 +345,85 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 	if strings.Contains(desc, errStringUnableToParse) {
 		log.Printf("E! [outputs.influxdb]: when writing to [%s]: received error %v; discarding points",
 			c.URL(), desc)
-		return nil
+		return c.partialWriteError(metrics, apiErr)
 	}
 
-	return &APIError{
-		StatusCode:  resp.StatusCode,
-		Title:       resp.Status,
-		Description: desc,
+	return apiErr
+}
+
+// partialWriteError reports the metrics identified by a partial write error
+// as rejected and all other metrics as accepted, since InfluxDB has written
+// them. If no metric can be identified then nil is returned.
+//
+// When InfluxDB reports more dropped points than could be identified, it is
+// not known which metrics were written and the whole batch is rejected.
+func (c *httpClient) partialWriteError(metrics []telegraf.Metric, apiErr *APIError) error {
+	conflict := fieldTypeConflictRe.FindStringSubmatch(apiErr.Description)
+
+	var accept, reject []int
+	for i, m := range metrics {
+		if conflict != nil && hasFieldOfType(m, conflict[2], conflict[1], conflict[3]) {
+			reject = append(reject, i)
+			continue
+		}
+
+		if strings.Contains(apiErr.Description, errStringUnableToParse) {
+			octets, err := c.serializer.Serialize(m)
+			if err == nil {
+				line := strings.TrimSuffix(string(octets), "\n")
+				if strings.Contains(apiErr.Description, "'"+line+"'") {
+					reject = append(reject, i)
+					continue
+				}
+			}
+		}
+		accept = append(accept, i)
+	}
+
+	if dropped := droppedRe.FindStringSubmatch(apiErr.Description); dropped != nil {
+		n, err := strconv.Atoi(dropped[1])
+		if err == nil && n > len(reject) {
+			accept = nil
+			reject = make([]int, len(metrics))
+			for i := range metrics {
+				reject[i] = i
+			}
+		}
 	}
+
+	if len(reject) == 0 {
+		return nil
+	}
+	return &telegraf.PartialWriteError{
+		Err:           apiErr,
+		MetricsAccept: accept,
+		MetricsReject: reject,
+	}
+}
+
+// hasFieldOfType returns true if the metric has the measurement name and a
+// field with the key and InfluxDB type.
+func hasFieldOfType(m telegraf.Metric, name, key, typ string) bool {
+	if m.Name() != name {
+		return false
+	}
+	value, ok := m.GetField(key)
+	if !ok {
+		return false
+	}
+	switch value.(type) {
+	case float64:
+		return typ == "float"
+	case int64:
+		return typ == "integer"
+	case uint64:
+		return typ == "unsigned"
+	case string:
+		return typ == "string"
+	case bool:
+		return typ == "boolean"
+	}
+	return false
 }
 
 func (c *httpClient) makeQueryRequest(query string) (*http.Request, error) {
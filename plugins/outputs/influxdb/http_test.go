@@ -386,6 +386,56 @@ func TestHTTP_Write(t *testing.T) {
 				require.Contains(t, str, "unable to parse")
 			},
 		},
+		{
+			name: "field type conflict rejects metric",
+			config: &influxdb.HTTPConfig{
+				URL:      u,
+				Database: "telegraf",
+			},
+			queryHandlerFunc: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error": "partial write: field type conflict: input field \"value\" on measurement \"cpu\" is type float, already exists as type integer dropped=1"}`))
+			},
+			errFunc: func(t *testing.T, err error) {
+				perr, ok := err.(*telegraf.PartialWriteError)
+				require.True(t, ok)
+				require.Equal(t, []int{0}, perr.MetricsReject)
+				require.Empty(t, perr.MetricsAccept)
+			},
+		},
+		{
+			name: "unidentified dropped points reject batch",
+			config: &influxdb.HTTPConfig{
+				URL:      u,
+				Database: "telegraf",
+			},
+			queryHandlerFunc: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error": "partial write: field type conflict: input field \"value\" on measurement \"mem\" is type float, already exists as type integer dropped=2"}`))
+			},
+			errFunc: func(t *testing.T, err error) {
+				perr, ok := err.(*telegraf.PartialWriteError)
+				require.True(t, ok)
+				require.Equal(t, []int{0}, perr.MetricsReject)
+				require.Empty(t, perr.MetricsAccept)
+			},
+		},
+		{
+			name: "parse error rejects metric",
+			config: &influxdb.HTTPConfig{
+				URL:      u,
+				Database: "telegraf",
+			},
+			queryHandlerFunc: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error": "unable to parse 'cpu value=42 0': bad timestamp"}`))
+			},
+			errFunc: func(t *testing.T, err error) {
+				perr, ok := err.(*telegraf.PartialWriteError)
+				require.True(t, ok)
+				require.Equal(t, []int{0}, perr.MetricsReject)
+			},
+		},
 		{
 			name: "http error",
 			config: &influxdb.HTTPConfig{
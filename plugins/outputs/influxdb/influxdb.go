@@ -203,6 +203,12 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 			return nil
 		}
 
+		// The server has written the batch except for rejected points,
+		// so don't try another server.
+		if _, ok := err.(*telegraf.PartialWriteError); ok {
+			return err
+		}
+
 		switch apiError := err.(type) {
 		case *APIError:
 			if !i.SkipDatabaseCreation {
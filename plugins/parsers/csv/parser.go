@@ -0,0 +1,254 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// Parser parses delimited text, each row being a metric and each column a
+// field, tag, the metric name or its time.
+type Parser struct {
+	MetricName string
+	// HeaderRowCount is the number of header rows holding the column names,
+	// the names of several rows being concatenated. ColumnNames overrides
+	// them.
+	HeaderRowCount int
+	ColumnNames    []string
+	// SkipRows is the number of rows skipped before the header, and
+	// SkipColumns the number of columns skipped on the left of each row.
+	SkipRows    int
+	SkipColumns int
+	// Delimiter separates the columns, "," if empty, and Comment starts the
+	// rows ignored.
+	Delimiter string
+	Comment   string
+	TrimSpace bool
+	// TagColumns are the columns added as tags, MeasurementColumn the column
+	// of the metric name and TimestampColumn the column of the metric time,
+	// parsed with TimestampFormat.
+	TagColumns        []string
+	MeasurementColumn string
+	TimestampColumn   string
+	TimestampFormat   string
+	DefaultTags       map[string]string
+
+	TimeFunc metric.TimeFunc
+}
+
+// NewParser returns a Parser checking its configuration.
+func NewParser(p *Parser) (*Parser, error) {
+	if p.HeaderRowCount == 0 && len(p.ColumnNames) == 0 {
+		return nil, errors.New("csv_header_row_count or csv_column_names must be set")
+	}
+	if utf8.RuneCountInString(p.Delimiter) > 1 {
+		return nil, fmt.Errorf("csv_delimiter %q must be a single character", p.Delimiter)
+	}
+	if utf8.RuneCountInString(p.Comment) > 1 {
+		return nil, fmt.Errorf("csv_comment %q must be a single character", p.Comment)
+	}
+	delimiter, comment := ",", p.Comment
+	if p.Delimiter != "" {
+		delimiter = p.Delimiter
+	}
+	if delimiter == comment {
+		return nil, fmt.Errorf("csv_delimiter and csv_comment must differ, both are %q", delimiter)
+	}
+	for _, c := range []string{delimiter, comment} {
+		if c == "\"" || c == "\r" || c == "\n" {
+			return nil, fmt.Errorf("csv_delimiter and csv_comment cannot be %q", c)
+		}
+	}
+	if p.TimestampColumn != "" && p.TimestampFormat == "" {
+		return nil, errors.New("csv_timestamp_format must be set with csv_timestamp_column")
+	}
+	if p.TimeFunc == nil {
+		p.TimeFunc = time.Now
+	}
+	return p, nil
+}
+
+func (p *Parser) newReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = p.TrimSpace
+	if p.Delimiter != "" {
+		reader.Comma, _ = utf8.DecodeRuneInString(p.Delimiter)
+	}
+	if p.Comment != "" {
+		reader.Comment, _ = utf8.DecodeRuneInString(p.Comment)
+	}
+	return reader
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	reader := p.newReader(bytes.NewReader(buf))
+
+	for i := 0; i < p.SkipRows; i++ {
+		if _, err := reader.Read(); err != nil {
+			if err == io.EOF {
+				return []telegraf.Metric{}, nil
+			}
+			return nil, err
+		}
+	}
+
+	names := p.ColumnNames
+	var header []string
+	for i := 0; i < p.HeaderRowCount; i++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return []telegraf.Metric{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		record = p.skipColumns(record)
+		for j, name := range record {
+			if j == len(header) {
+				header = append(header, "")
+			}
+			header[j] += strings.TrimSpace(name)
+		}
+	}
+	if len(names) == 0 {
+		names = header
+	}
+
+	now := p.TimeFunc()
+	metrics := make([]telegraf.Metric, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return metrics, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		m, err := p.parseRecord(names, p.skipColumns(record), now)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+}
+
+// ParseLine parses a row without header, ColumnNames being required.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	if len(p.ColumnNames) == 0 {
+		return nil, errors.New("csv_column_names must be set to parse lines")
+	}
+
+	record, err := p.newReader(strings.NewReader(line)).Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("Can not parse the line: %s, for data format: csv", line)
+		}
+		return nil, err
+	}
+	m, err := p.parseRecord(p.ColumnNames, p.skipColumns(record), p.TimeFunc())
+	if err == nil && m == nil {
+		err = fmt.Errorf("Can not parse the line: %s, for data format: csv", line)
+	}
+	return m, err
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) skipColumns(record []string) []string {
+	if p.SkipColumns >= len(record) {
+		return nil
+	}
+	return record[p.SkipColumns:]
+}
+
+// parseRecord returns the metric of a row, or nil if it has no fields. The
+// columns without a name and the empty values are ignored.
+func (p *Parser) parseRecord(
+	names []string,
+	record []string,
+	now time.Time,
+) (telegraf.Metric, error) {
+	name := p.MetricName
+	t := now
+	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	fields := make(map[string]interface{})
+
+	for i, value := range record {
+		if i >= len(names) || names[i] == "" {
+			continue
+		}
+		if p.TrimSpace {
+			value = strings.TrimSpace(value)
+		}
+		if value == "" {
+			continue
+		}
+
+		column := names[i]
+		switch {
+		case column == p.MeasurementColumn:
+			name = value
+		case column == p.TimestampColumn:
+			var err error
+			t, err = internal.ParseTimestamp(value, p.TimestampFormat)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp in column %s: %v",
+					column, err)
+			}
+		case p.isTagColumn(column):
+			tags[column] = value
+		default:
+			fields[column] = parseValue(value)
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return metric.New(name, tags, fields, t)
+}
+
+func (p *Parser) isTagColumn(column string) bool {
+	for _, tag := range p.TagColumns {
+		if tag == column {
+			return true
+		}
+	}
+	return false
+}
+
+// parseValue returns the integer, float or boolean of a value, or the value
+// itself if it is none of them.
+func parseValue(value string) interface{} {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	switch {
+	case strings.EqualFold(value, "true"):
+		return true
+	case strings.EqualFold(value, "false"):
+		return false
+	}
+	return value
+}
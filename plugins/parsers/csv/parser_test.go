@@ -0,0 +1,178 @@
+package csv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var defaultTime = time.Unix(3600, 0)
+
+func defaultTimeFunc() time.Time {
+	return defaultTime
+}
+
+func TestHeader(t *testing.T) {
+	parser, err := NewParser(&Parser{
+		MetricName:     "csv",
+		HeaderRowCount: 1,
+		TimeFunc:       defaultTimeFunc,
+	})
+	require.NoError(t, err)
+
+	metrics, err := parser.Parse([]byte("a,b,c,d\n1,2.5,true,on\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "csv", metrics[0].Name())
+	assert.Equal(t, map[string]interface{}{
+		"a": int64(1),
+		"b": 2.5,
+		"c": true,
+		"d": "on",
+	}, metrics[0].Fields())
+	assert.Equal(t, defaultTime, metrics[0].Time())
+}
+
+func TestMultipleHeaderRows(t *testing.T) {
+	parser, err := NewParser(&Parser{
+		MetricName:     "csv",
+		HeaderRowCount: 2,
+		SkipRows:       1,
+		SkipColumns:    1,
+		TimeFunc:       defaultTimeFunc,
+	})
+	require.NoError(t, err)
+
+	metrics, err := parser.Parse([]byte("report generated\nid,cpu,mem\n0,_usage,_used\n0,10,20\n1,30,40\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, map[string]interface{}{"cpu_usage": int64(10), "mem_used": int64(20)}, metrics[0].Fields())
+	assert.Equal(t, map[string]interface{}{"cpu_usage": int64(30), "mem_used": int64(40)}, metrics[1].Fields())
+}
+
+func TestColumns(t *testing.T) {
+	parser, err := NewParser(&Parser{
+		MetricName:        "csv",
+		HeaderRowCount:    1,
+		ColumnNames:       []string{"name", "host", "time", "value", "", "extra"},
+		Delimiter:         ";",
+		Comment:           "#",
+		TrimSpace:         true,
+		TagColumns:        []string{"host"},
+		MeasurementColumn: "name",
+		TimestampColumn:   "time",
+		TimestampFormat:   "2006-01-02T15:04:05Z07:00",
+		DefaultTags:       map[string]string{"region": "east"},
+		TimeFunc:          defaultTimeFunc,
+	})
+	require.NoError(t, err)
+
+	metrics, err := parser.Parse([]byte("n;h;t;v;x\n# comment\ncpu; web1 ; 2018-07-10T08:00:00Z; 42;ignored\nmem;web2;;;\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "cpu", metrics[0].Name())
+	assert.Equal(t, map[string]string{"host": "web1", "region": "east"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{"value": int64(42)}, metrics[0].Fields())
+	assert.Equal(t, time.Unix(1531209600, 0).UTC(), metrics[0].Time())
+}
+
+func TestTimestampUnix(t *testing.T) {
+	parser, err := NewParser(&Parser{
+		MetricName:      "csv",
+		ColumnNames:     []string{"time", "value"},
+		TimestampColumn: "time",
+		TimestampFormat: "unix_ms",
+		TimeFunc:        defaultTimeFunc,
+	})
+	require.NoError(t, err)
+
+	metrics, err := parser.Parse([]byte("1531209600123,1\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, time.Unix(1531209600, 123e6).UTC(), metrics[0].Time())
+
+	_, err = parser.Parse([]byte("yesterday,1\n"))
+	assert.Error(t, err)
+}
+
+func TestParseLine(t *testing.T) {
+	parser, err := NewParser(&Parser{
+		MetricName:  "csv",
+		ColumnNames: []string{"host", "value"},
+		TagColumns:  []string{"host"},
+		TimeFunc:    defaultTimeFunc,
+	})
+	require.NoError(t, err)
+
+	m, err := parser.ParseLine("web1,42")
+	require.NoError(t, err)
+	expected, _ := metric.New("csv",
+		map[string]string{"host": "web1"},
+		map[string]interface{}{"value": int64(42)},
+		defaultTime,
+	)
+	assert.Equal(t, expected, m)
+
+	_, err = parser.ParseLine("web1,")
+	assert.Error(t, err)
+}
+
+func TestParseLineHeader(t *testing.T) {
+	parser, err := NewParser(&Parser{
+		MetricName:     "csv",
+		HeaderRowCount: 1,
+		TimeFunc:       defaultTimeFunc,
+	})
+	require.NoError(t, err)
+
+	_, err = parser.ParseLine("web1,42")
+	assert.Error(t, err)
+}
+
+func TestNewParserInvalid(t *testing.T) {
+	tests := []struct {
+		message string
+		parser  Parser
+	}{
+		{
+			message: "Should require header rows or column names",
+			parser:  Parser{},
+		},
+		{
+			message: "Should reject multi character delimiter",
+			parser:  Parser{HeaderRowCount: 1, Delimiter: "::"},
+		},
+		{
+			message: "Should require timestamp format with timestamp column",
+			parser:  Parser{HeaderRowCount: 1, TimestampColumn: "time"},
+		},
+		{
+			message: "Should reject delimiter equal to comment",
+			parser:  Parser{HeaderRowCount: 1, Delimiter: "#", Comment: "#"},
+		},
+		{
+			message: "Should reject comment equal to default delimiter",
+			parser:  Parser{HeaderRowCount: 1, Comment: ","},
+		},
+		{
+			message: "Should reject quote delimiter",
+			parser:  Parser{HeaderRowCount: 1, Delimiter: "\""},
+		},
+		{
+			message: "Should reject newline comment",
+			parser:  Parser{HeaderRowCount: 1, Comment: "\n"},
+		},
+	}
+
+	for _, test := range tests {
+		parser := test.parser
+		_, err := NewParser(&parser)
+		assert.Error(t, err, test.message)
+	}
+
+	_, err := NewParser(&Parser{HeaderRowCount: 1, Delimiter: ";", Comment: "#"})
+	assert.NoError(t, err, "Should accept distinct delimiter and comment")
+}
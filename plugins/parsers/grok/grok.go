@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/vjeantet/grok"
@@ -72,6 +73,7 @@ type Parser struct {
 	CustomPatterns     string
 	CustomPatternFiles []string
 	Measurement        string
+	DefaultTags        map[string]string
 
 	// Timezone is an optional component to help render log dates to
 	// your chosen zone.
@@ -117,10 +119,26 @@ type Parser struct {
 	timeFunc func() time.Time
 	g        *grok.Grok
 	tsModder *tsModder
+
+	// compileOnce compiles the patterns a single time, the compiled patterns
+	// being shared by the calls to Parse and ParseLine. mu guards the state
+	// updated by the parsing, foundTsLayouts and tsModder.
+	compileOnce sync.Once
+	compileErr  error
+	mu          sync.Mutex
 }
 
-// Compile is a bound method to Parser which will process the options for our parser
+// Compile is a bound method to Parser which will process the options for our
+// parser. The patterns are compiled on the first call only, the following
+// calls returning its result.
 func (p *Parser) Compile() error {
+	p.compileOnce.Do(func() {
+		p.compileErr = p.compile()
+	})
+	return p.compileErr
+}
+
+func (p *Parser) compile() error {
 	p.typeMap = make(map[string]map[string]string)
 	p.tsMap = make(map[string]map[string]string)
 	p.patterns = make(map[string]string)
@@ -181,10 +199,46 @@ func (p *Parser) Compile() error {
 		p.timeFunc = time.Now
 	}
 
-	return p.compileCustomPatterns()
+	if err := p.compileCustomPatterns(); err != nil {
+		return err
+	}
+
+	// Parse an empty line with each pattern, so that the grok regexps are
+	// compiled and cached before ParseLine is called concurrently. Patterns
+	// that fail to compile return their error from ParseLine.
+	for _, pattern := range p.namedPatterns {
+		p.g.Parse(pattern, "")
+	}
+	return nil
 }
 
-// ParseLine is the primary function to process individual lines, returning the metrics
+// Parse parses each line of buf, skipping the lines not matching any of the
+// patterns, and logging and skipping those that cannot be parsed.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+	for _, line := range strings.Split(string(buf), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		m, err := p.ParseLine(line)
+		if err != nil {
+			log.Printf("E! Error parsing line %q: %s", line, err)
+			continue
+		}
+		if m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// ParseLine is the primary function to process individual lines, returning the metrics.
+// A line not matching any of the patterns returns a nil metric and no error.
 func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	var err error
 	// values are the parsed fields from the log line
@@ -208,6 +262,9 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 
 	fields := make(map[string]interface{})
 	tags := make(map[string]string)
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
 	timestamp := time.Now()
 	for k, v := range values {
 		if k == "" || v == "" {
@@ -303,6 +360,7 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 				log.Printf("E! Error parsing %s to time layout [%s]: %s", v, t, err)
 			}
 		case GENERIC_TIMESTAMP:
+			p.mu.Lock()
 			var foundTs bool
 			// first try timestamp layouts that we've already found
 			for _, layout := range p.foundTsLayouts {
@@ -332,6 +390,7 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 				log.Printf("E! Error parsing timestamp [%s], could not find any "+
 					"suitable time layouts.", v)
 			}
+			p.mu.Unlock()
 		case DROP:
 		// goodbye!
 		default:
@@ -348,7 +407,10 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 		return nil, fmt.Errorf("logparser_grok: must have one or more fields")
 	}
 
-	return metric.New(p.Measurement, tags, fields, p.tsModder.tsMod(timestamp))
+	p.mu.Lock()
+	timestamp = p.tsModder.tsMod(timestamp)
+	p.mu.Unlock()
+	return metric.New(p.Measurement, tags, fields, timestamp)
 }
 
 func (p *Parser) addCustomPatterns(scanner *bufio.Scanner) {
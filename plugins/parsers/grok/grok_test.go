@@ -1,6 +1,7 @@
 package grok
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -1007,3 +1008,71 @@ func TestSyslogTimestamp(t *testing.T) {
 		})
 	}
 }
+
+func TestParse(t *testing.T) {
+	p := &Parser{
+		Measurement: "myapp",
+		Patterns:    []string{`%{NUMBER:ts:ts-epoch} %{WORD:level:tag} value=%{NUMBER:value:int}`},
+		DefaultTags: map[string]string{"host": "web1"},
+	}
+	require.NoError(t, p.Compile())
+
+	metrics, err := p.Parse([]byte("1466004605 INFO value=42\r\nnot a log line\n\n1466004606 WARN value=7\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "myapp", metrics[0].Name())
+	assert.Equal(t, map[string]string{"host": "web1", "level": "INFO"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{"value": int64(42)}, metrics[0].Fields())
+	assert.Equal(t, time.Unix(1466004605, 0), metrics[0].Time())
+	assert.Equal(t, map[string]string{"host": "web1", "level": "WARN"}, metrics[1].Tags())
+	assert.Equal(t, map[string]interface{}{"value": int64(7)}, metrics[1].Fields())
+}
+
+func TestParseSkipsLinesWithoutFields(t *testing.T) {
+	p := &Parser{
+		Measurement: "myapp",
+		Patterns:    []string{`%{NUMBER:ts:ts-epoch} %{WORD:level:tag}( value=%{NUMBER:value:int})?`},
+	}
+	require.NoError(t, p.Compile())
+
+	metrics, err := p.Parse([]byte("1466004605 INFO\n1466004606 WARN value=7\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]string{"level": "WARN"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{"value": int64(7)}, metrics[0].Fields())
+}
+
+func TestCompileOnce(t *testing.T) {
+	p := &Parser{
+		Patterns: []string{"%{TEST_LOG_A}"},
+		CustomPatterns: `
+			TEST_LOG_A %{NUMBER:myfloat:float} %{NUMBER:mynum:int}
+		`,
+	}
+	require.NoError(t, p.Compile())
+	customPatterns := p.CustomPatterns
+	require.NoError(t, p.Compile())
+	assert.Equal(t, customPatterns, p.CustomPatterns)
+	assert.Equal(t, []string{"%{GROK_INTERNAL_PATTERN_0}"}, p.namedPatterns)
+}
+
+func TestParseConcurrent(t *testing.T) {
+	p := &Parser{
+		Patterns: []string{`%{HTTPDATE:ts:ts} %{NUMBER:value:int}`},
+	}
+	require.NoError(t, p.Compile())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				metrics, err := p.Parse([]byte("04/Jun/2016:12:41:45 +0100 42\n"))
+				assert.NoError(t, err)
+				assert.Len(t, metrics, 1)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -3,6 +3,7 @@ package json
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -10,35 +11,118 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/tidwall/gjson"
 )
 
 var (
 	utf8BOM = []byte("\xef\xbb\xbf")
 )
 
+// JSONParser parses JSON objects as metrics, flattening nested objects and
+// arrays into fields. The keys of the tags and fields are the names of the
+// flattened fields, in which the keys of nested objects are joined with "_".
+// Their paths, joined with ".", are accepted too.
 type JSONParser struct {
 	MetricName  string
 	TagKeys     []string
 	DefaultTags map[string]string
+
+	// Query is a GJSON path to the object or array of objects to parse,
+	// the whole document if empty. Each object of the array, or of nested
+	// arrays, is parsed as a metric.
+	Query string
+	// NameKey is the key of the metric name, MetricName being used if
+	// empty or missing.
+	NameKey string
+	// StringFields are the keys of the strings kept as fields, and
+	// FieldKeys the keys of the other fields kept, all of them if empty.
+	// Both accept globs.
+	StringFields []string
+	FieldKeys    []string
+	// TimeKey is the key of the metric time, parsed with TimeFormat: a Go
+	// time layout, or "unix", "unix_ms", "unix_us" or "unix_ns" for the
+	// time since the Unix epoch. The time of the parsing is used if empty.
+	TimeKey    string
+	TimeFormat string
+
+	// The filters of the fields, set by Compile.
+	stringFieldFilter filter.Filter
+	fieldKeyFilter    filter.Filter
 }
 
-func (p *JSONParser) parseArray(buf []byte) ([]telegraf.Metric, error) {
-	metrics := make([]telegraf.Metric, 0)
+// flatKey returns the name of the flattened field of a key or path.
+func flatKey(key string) string {
+	return strings.Replace(key, ".", "_", -1)
+}
 
-	var jsonOut []map[string]interface{}
-	err := json.Unmarshal(buf, &jsonOut)
+func flatKeys(keys []string) []string {
+	flat := make([]string, 0, len(keys))
+	for _, key := range keys {
+		flat = append(flat, flatKey(key))
+	}
+	return flat
+}
+
+// Compile compiles the globs of StringFields and FieldKeys. It must be
+// called before parsing if either is set.
+func (p *JSONParser) Compile() error {
+	var err error
+	p.stringFieldFilter, err = filter.Compile(flatKeys(p.StringFields))
 	if err != nil {
-		err = fmt.Errorf("unable to parse out as JSON Array, %s", err)
-		return nil, err
+		return fmt.Errorf("invalid json_string_fields: %s", err)
+	}
+	p.fieldKeyFilter, err = filter.Compile(flatKeys(p.FieldKeys))
+	if err != nil {
+		return fmt.Errorf("invalid json_field_keys: %s", err)
+	}
+	return nil
+}
+
+// numberValue returns the int64 of an integer JSON number, so that it keeps
+// its precision, and the float64 of other numbers.
+func numberValue(n json.Number) (interface{}, error) {
+	if i, err := n.Int64(); err == nil {
+		return i, nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s as a number", n)
 	}
-	for _, item := range jsonOut {
-		metrics, err = p.parseObject(metrics, item)
+	return f, nil
+}
+
+// parseValue parses each object of the value as a metric, recursing into
+// nested arrays.
+func (p *JSONParser) parseValue(
+	metrics []telegraf.Metric,
+	v interface{},
+	now time.Time,
+) ([]telegraf.Metric, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return p.parseObject(metrics, v, now)
+	case []interface{}:
+		var err error
+		for _, item := range v {
+			metrics, err = p.parseValue(metrics, item, now)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return metrics, nil
+	default:
+		return nil, fmt.Errorf("unable to parse %T as a JSON object", v)
 	}
-	return metrics, nil
 }
 
-func (p *JSONParser) parseObject(metrics []telegraf.Metric, jsonOut map[string]interface{}) ([]telegraf.Metric, error) {
+func (p *JSONParser) parseObject(
+	metrics []telegraf.Metric,
+	jsonOut map[string]interface{},
+	now time.Time,
+) ([]telegraf.Metric, error) {
 
 	tags := make(map[string]string)
 	for k, v := range p.DefaultTags {
@@ -51,9 +135,48 @@ func (p *JSONParser) parseObject(metrics []telegraf.Metric, jsonOut map[string]i
 		return nil, err
 	}
 
+	name := p.MetricName
+	if p.NameKey != "" {
+		key := flatKey(p.NameKey)
+		if value, ok := f.Fields[key].(string); ok && value != "" {
+			name = value
+		}
+		delete(f.Fields, key)
+	}
+
+	t := now
+	if p.TimeKey != "" {
+		key := flatKey(p.TimeKey)
+		value, ok := f.Fields[key]
+		if !ok {
+			return nil, fmt.Errorf("time key %q not found", p.TimeKey)
+		}
+		if n, ok := value.(json.Number); ok {
+			if value, err = numberValue(n); err != nil {
+				return nil, err
+			}
+		}
+		t, err = internal.ParseTimestamp(value, p.TimeFormat)
+		if err != nil {
+			return nil, err
+		}
+		delete(f.Fields, key)
+	}
+
+	// The other numbers are fields, which are floats.
+	for k, v := range f.Fields {
+		if n, ok := v.(json.Number); ok {
+			value, err := n.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse %s as a number", n)
+			}
+			f.Fields[k] = value
+		}
+	}
+
 	tags, nFields := p.switchFieldToTag(tags, f.Fields)
 
-	metric, err := metric.New(p.MetricName, tags, nFields, time.Now().UTC())
+	metric, err := metric.New(name, tags, nFields, t)
 
 	if err != nil {
 		return nil, err
@@ -66,7 +189,7 @@ func (p *JSONParser) parseObject(metrics []telegraf.Metric, jsonOut map[string]i
 //will delete any strings/bools that shouldn't be fields
 //assumes that any non-numeric values in TagKeys should be displayed as tags
 func (p *JSONParser) switchFieldToTag(tags map[string]string, fields map[string]interface{}) (map[string]string, map[string]interface{}) {
-	for _, name := range p.TagKeys {
+	for _, name := range flatKeys(p.TagKeys) {
 		//switch any fields in tagkeys into tags
 		if fields[name] == nil {
 			continue
@@ -86,13 +209,20 @@ func (p *JSONParser) switchFieldToTag(tags map[string]string, fields map[string]
 		}
 	}
 
-	//remove any additional string/bool values from fields
+	//remove any additional string/bool values from fields, and the fields
+	//not selected
 	for k := range fields {
 		switch fields[k].(type) {
 		case string:
-			delete(fields, k)
+			if p.stringFieldFilter == nil || !p.stringFieldFilter.Match(k) {
+				delete(fields, k)
+			}
 		case bool:
 			delete(fields, k)
+		default:
+			if p.fieldKeyFilter != nil && !p.fieldKeyFilter.Match(k) {
+				delete(fields, k)
+			}
 		}
 	}
 	return tags, fields
@@ -105,17 +235,28 @@ func (p *JSONParser) Parse(buf []byte) ([]telegraf.Metric, error) {
 		return make([]telegraf.Metric, 0), nil
 	}
 
-	if !isarray(buf) {
-		metrics := make([]telegraf.Metric, 0)
-		var jsonOut map[string]interface{}
-		err := json.Unmarshal(buf, &jsonOut)
-		if err != nil {
-			err = fmt.Errorf("unable to parse out as JSON, %s", err)
-			return nil, err
+	if p.Query != "" {
+		result := gjson.GetBytes(buf, p.Query)
+		if !result.Exists() {
+			return nil, fmt.Errorf("query %q not found in JSON", p.Query)
 		}
-		return p.parseObject(metrics, jsonOut)
+		buf = []byte(result.Raw)
 	}
-	return p.parseArray(buf)
+
+	// Numbers are decoded as json.Number, so that integer times keep their
+	// precision.
+	var jsonOut interface{}
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.UseNumber()
+	err := decoder.Decode(&jsonOut)
+	if err == nil && decoder.More() {
+		err = errors.New("invalid character after top-level value")
+	}
+	if err != nil {
+		err = fmt.Errorf("unable to parse out as JSON, %s", err)
+		return nil, err
+	}
+	return p.parseValue(make([]telegraf.Metric, 0), jsonOut, time.Now().UTC())
 }
 
 func (p *JSONParser) ParseLine(line string) (telegraf.Metric, error) {
@@ -180,6 +321,10 @@ func (f *JSONFlattener) FullFlattenJSON(
 		}
 	case float64:
 		f.Fields[fieldname] = t
+	case json.Number:
+		// Decoded with UseNumber, the number is kept for the caller to
+		// convert.
+		f.Fields[fieldname] = t
 	case string:
 		if convertString {
 			f.Fields[fieldname] = v.(string)
@@ -200,13 +345,3 @@ func (f *JSONFlattener) FullFlattenJSON(
 	}
 	return nil
 }
-
-func isarray(buf []byte) bool {
-	ia := bytes.IndexByte(buf, '[')
-	ib := bytes.IndexByte(buf, '{')
-	if ia > -1 && ia < ib {
-		return true
-	} else {
-		return false
-	}
-}
@@ -2,6 +2,7 @@ package json
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -467,3 +468,152 @@ func TestJSONParseNestedArray(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, len(parser.TagKeys), len(metrics[0].Tags()))
 }
+
+const vendorJSON = `
+{
+    "status": "ok",
+    "data": {
+        "hosts": [
+            {
+                "name": "web1",
+                "site": {"dc": "east"},
+                "kind": "server",
+                "version": "1.2",
+                "cpu": 0.5,
+                "mem": 128,
+                "time": "2018-07-10T08:00:00Z",
+                "epoch": 1531209600000
+            },
+            [
+                {
+                    "name": "web2",
+                    "site": {"dc": "west"},
+                    "kind": "vm",
+                    "version": "1.3",
+                    "cpu": 0.25,
+                    "mem": 256,
+                    "time": "2018-07-10T08:00:10Z",
+                    "epoch": 1531209610000
+                }
+            ]
+        ]
+    }
+}
+`
+
+func TestParseQuery(t *testing.T) {
+	parser := JSONParser{
+		MetricName:   "json_test",
+		Query:        "data.hosts",
+		TagKeys:      []string{"name", "site.dc"},
+		NameKey:      "kind",
+		StringFields: []string{"vers*"},
+		FieldKeys:    []string{"cpu"},
+		TimeKey:      "time",
+		TimeFormat:   time.RFC3339,
+	}
+	require.NoError(t, parser.Compile())
+
+	metrics, err := parser.Parse([]byte(vendorJSON))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	assert.Equal(t, "server", metrics[0].Name())
+	assert.Equal(t, map[string]string{"name": "web1", "site_dc": "east"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{"cpu": 0.5, "version": "1.2"}, metrics[0].Fields())
+	assert.Equal(t, time.Unix(1531209600, 0).UTC(), metrics[0].Time())
+
+	assert.Equal(t, "vm", metrics[1].Name())
+	assert.Equal(t, map[string]string{"name": "web2", "site_dc": "west"}, metrics[1].Tags())
+	assert.Equal(t, map[string]interface{}{"cpu": 0.25, "version": "1.3"}, metrics[1].Fields())
+	assert.Equal(t, time.Unix(1531209610, 0).UTC(), metrics[1].Time())
+}
+
+func TestParseQueryNotFound(t *testing.T) {
+	parser := JSONParser{
+		MetricName: "json_test",
+		Query:      "data.missing",
+	}
+
+	_, err := parser.Parse([]byte(vendorJSON))
+	assert.Error(t, err)
+}
+
+func TestParseTimeUnix(t *testing.T) {
+	tests := []struct {
+		format string
+		input  string
+		time   time.Time
+	}{
+		{"unix", `{"t": 1531209600.5}`, time.Unix(1531209600, 5e8)},
+		{"unix", `{"t": "1531209600"}`, time.Unix(1531209600, 0)},
+		{"unix_ms", `{"t": 1531209600123}`, time.Unix(1531209600, 123e6)},
+		{"unix_us", `{"t": 1531209600123456}`, time.Unix(1531209600, 123456e3)},
+		{"unix_ns", `{"t": 1531209600}`, time.Unix(1, 531209600)},
+		{"unix_ns", `{"t": 1531209600123456789}`, time.Unix(1531209600, 123456789)},
+		{"unix_us", `{"t": 1531209600123457}`, time.Unix(1531209600, 123457e3)},
+	}
+	for _, tt := range tests {
+		parser := JSONParser{
+			MetricName: "json_test",
+			TimeKey:    "t",
+			TimeFormat: tt.format,
+		}
+		metrics, err := parser.Parse([]byte(tt.input))
+		require.NoError(t, err)
+		require.Len(t, metrics, 1)
+		assert.Equal(t, tt.time.UnixNano(), metrics[0].Time().UnixNano(), tt.input)
+		assert.Empty(t, metrics[0].Fields())
+	}
+}
+
+func TestParseTimeInvalid(t *testing.T) {
+	parser := JSONParser{
+		MetricName: "json_test",
+		TimeKey:    "t",
+		TimeFormat: "unix",
+	}
+	_, err := parser.Parse([]byte(`{"a": 1}`))
+	assert.Error(t, err)
+	_, err = parser.Parse([]byte(`{"a": 1, "t": "yesterday"}`))
+	assert.Error(t, err)
+}
+
+func TestParseNumbers(t *testing.T) {
+	parser := JSONParser{
+		MetricName: "json_test",
+		TagKeys:    []string{"id"},
+	}
+	metrics, err := parser.Parse([]byte(`{"id": 42, "a": 1, "b": 1.5}`))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]string{"id": "42"}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{"a": 1.0, "b": 1.5}, metrics[0].Fields())
+
+	_, err = parser.Parse([]byte(`{"a": 1e400}`))
+	assert.Error(t, err)
+	_, err = parser.Parse([]byte(`{"a": 1} {"a": 2}`))
+	assert.Error(t, err)
+}
+
+func TestCompileInvalid(t *testing.T) {
+	parser := JSONParser{
+		MetricName:   "json_test",
+		StringFields: []string{"[a"},
+	}
+	assert.Error(t, parser.Compile())
+
+	parser = JSONParser{
+		MetricName: "json_test",
+		FieldKeys:  []string{"[a"},
+	}
+	assert.Error(t, parser.Compile())
+}
+
+func TestParseArrayOfValues(t *testing.T) {
+	parser := JSONParser{
+		MetricName: "json_test",
+	}
+	_, err := parser.Parse([]byte(`[1, 2]`))
+	assert.Error(t, err)
+}
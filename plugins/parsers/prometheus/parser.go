@@ -6,6 +6,7 @@ package prometheus
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -21,9 +22,19 @@ import (
 	"github.com/prometheus/common/expfmt"
 )
 
+// Parser parses the Prometheus text exposition format, or the delimited
+// protocol buffer format when Header says so. The metrics have the value type
+// of their metric family.
+type Parser struct {
+	DefaultTags map[string]string
+	// Header is the header of the HTTP response the metrics were read from,
+	// if any.
+	Header http.Header
+}
+
 // Parse returns a slice of Metrics from a text representation of a
 // metrics
-func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	var metrics []telegraf.Metric
 	var parser expfmt.TextParser
 	// parse even if the buffer begins with a newline
@@ -32,13 +43,10 @@ func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 	buffer := bytes.NewBuffer(buf)
 	reader := bufio.NewReader(buffer)
 
-	mediatype, params, err := mime.ParseMediaType(header.Get("Content-Type"))
 	// Prepare output
 	metricFamilies := make(map[string]*dto.MetricFamily)
 
-	if err == nil && mediatype == "application/vnd.google.protobuf" &&
-		params["encoding"] == "delimited" &&
-		params["proto"] == "io.prometheus.client.MetricFamily" {
+	if p.isProtobuf() {
 		for {
 			mf := &dto.MetricFamily{}
 			if _, ierr := pbutil.ReadDelimited(reader, mf); ierr != nil {
@@ -50,17 +58,19 @@ func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 			metricFamilies[mf.GetName()] = mf
 		}
 	} else {
+		var err error
 		metricFamilies, err = parser.TextToMetricFamilies(reader)
 		if err != nil {
 			return nil, fmt.Errorf("reading text format failed: %s", err)
 		}
 	}
 
+	now := time.Now()
 	// read metrics
 	for metricName, mf := range metricFamilies {
 		for _, m := range mf.Metric {
 			// reading tags
-			tags := makeLabels(m)
+			tags := p.makeLabels(m)
 			// reading fields
 			fields := make(map[string]interface{})
 			if mf.GetType() == dto.MetricType_SUMMARY {
@@ -80,11 +90,9 @@ func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 			}
 			// converting to telegraf metric
 			if len(fields) > 0 {
-				var t time.Time
+				t := now
 				if m.TimestampMs != nil && *m.TimestampMs > 0 {
 					t = time.Unix(0, *m.TimestampMs*1000000)
-				} else {
-					t = time.Now()
 				}
 				metric, err := metric.New(metricName, tags, fields, t, valueType(mf.GetType()))
 				if err == nil {
@@ -94,7 +102,31 @@ func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 		}
 	}
 
-	return metrics, err
+	return metrics, nil
+}
+
+// ParseLine returns an error: a sample cannot be parsed without the TYPE
+// line of its metric family, nor the samples of a summary or histogram
+// without each other, so the format must be parsed a whole exposition at a
+// time.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	return nil, errors.New("the prometheus data format cannot be parsed line by line")
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// isProtobuf returns true if the content type of the header is the
+// delimited protocol buffer format.
+func (p *Parser) isProtobuf() bool {
+	if p.Header == nil {
+		return false
+	}
+	mediatype, params, err := mime.ParseMediaType(p.Header.Get("Content-Type"))
+	return err == nil && mediatype == "application/vnd.google.protobuf" &&
+		params["encoding"] == "delimited" &&
+		params["proto"] == "io.prometheus.client.MetricFamily"
 }
 
 func valueType(mt dto.MetricType) telegraf.ValueType {
@@ -132,9 +164,12 @@ func makeBuckets(m *dto.Metric) map[string]interface{} {
 	return fields
 }
 
-// Get labels from metric
-func makeLabels(m *dto.Metric) map[string]string {
+// Get labels from metric, added to the default tags
+func (p *Parser) makeLabels(m *dto.Metric) map[string]string {
 	result := map[string]string{}
+	for k, v := range p.DefaultTags {
+		result[k] = v
+	}
 	for _, lp := range m.Label {
 		result[lp.GetName()] = lp.GetValue()
 	}
@@ -1,11 +1,12 @@
 package prometheus
 
 import (
-	"net/http"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var exptime = time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
@@ -103,7 +104,7 @@ cpu,host=foo,datacenter=us-east usage_idle=99,usage_busy=1
 
 func TestParseValidPrometheus(t *testing.T) {
 	// Gauge value
-	metrics, err := Parse([]byte(validUniqueGauge), http.Header{})
+	metrics, err := (&Parser{}).Parse([]byte(validUniqueGauge))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "cadvisor_version_info", metrics[0].Name())
@@ -119,7 +120,7 @@ func TestParseValidPrometheus(t *testing.T) {
 	}, metrics[0].Tags())
 
 	// Counter value
-	metrics, err = Parse([]byte(validUniqueCounter), http.Header{})
+	metrics, err = (&Parser{}).Parse([]byte(validUniqueCounter))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "get_token_fail_count", metrics[0].Name())
@@ -130,7 +131,7 @@ func TestParseValidPrometheus(t *testing.T) {
 
 	// Summary data
 	//SetDefaultTags(map[string]string{})
-	metrics, err = Parse([]byte(validUniqueSummary), http.Header{})
+	metrics, err = (&Parser{}).Parse([]byte(validUniqueSummary))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "http_request_duration_microseconds", metrics[0].Name())
@@ -144,7 +145,7 @@ func TestParseValidPrometheus(t *testing.T) {
 	assert.Equal(t, map[string]string{"handler": "prometheus"}, metrics[0].Tags())
 
 	// histogram data
-	metrics, err = Parse([]byte(validUniqueHistogram), http.Header{})
+	metrics, err = (&Parser{}).Parse([]byte(validUniqueHistogram))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "apiserver_request_latencies", metrics[0].Name())
@@ -165,3 +166,42 @@ func TestParseValidPrometheus(t *testing.T) {
 		metrics[0].Tags())
 
 }
+
+func TestParseValueType(t *testing.T) {
+	tests := []struct {
+		input     string
+		valueType telegraf.ValueType
+	}{
+		{validUniqueGauge, telegraf.Gauge},
+		{validUniqueCounter, telegraf.Counter},
+		{validUniqueSummary, telegraf.Summary},
+		{validUniqueHistogram, telegraf.Histogram},
+		{"untyped_metric 1\n", telegraf.Untyped},
+	}
+	for _, tt := range tests {
+		metrics, err := (&Parser{}).Parse([]byte(tt.input))
+		require.NoError(t, err)
+		require.Len(t, metrics, 1)
+		assert.Equal(t, tt.valueType, metrics[0].Type(), metrics[0].Name())
+	}
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	parser := Parser{}
+	parser.SetDefaultTags(map[string]string{"host": "localhost", "handler": "default"})
+
+	metrics, err := parser.Parse([]byte(validUniqueSummary))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]string{
+		"host":    "localhost",
+		"handler": "prometheus",
+	}, metrics[0].Tags())
+}
+
+func TestParseLine(t *testing.T) {
+	parser := Parser{}
+
+	_, err := parser.ParseLine(`http_requests_total{method="post",code="200"} 1027 1395066363000`)
+	assert.Error(t, err)
+}
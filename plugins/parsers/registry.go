@@ -6,11 +6,14 @@ import (
 	"github.com/influxdata/telegraf"
 
 	"github.com/influxdata/telegraf/plugins/parsers/collectd"
+	"github.com/influxdata/telegraf/plugins/parsers/csv"
 	"github.com/influxdata/telegraf/plugins/parsers/dropwizard"
 	"github.com/influxdata/telegraf/plugins/parsers/graphite"
+	"github.com/influxdata/telegraf/plugins/parsers/grok"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/plugins/parsers/nagios"
+	"github.com/influxdata/telegraf/plugins/parsers/prometheus"
 	"github.com/influxdata/telegraf/plugins/parsers/value"
 )
 
@@ -46,7 +49,8 @@ type Parser interface {
 // Config is a struct that covers the data types needed for all parser types,
 // and can be used to instantiate _any_ of the parsers.
 type Config struct {
-	// Dataformat can be one of: json, influx, graphite, value, nagios
+	// Dataformat can be one of: json, influx, graphite, value, nagios, csv,
+	// prometheus, grok
 	DataFormat string
 
 	// Separator only applied to Graphite data.
@@ -56,6 +60,17 @@ type Config struct {
 
 	// TagKeys only apply to JSON data
 	TagKeys []string
+	// JSONQuery is a GJSON path to the objects to parse as metrics
+	JSONQuery string
+	// JSONNameKey is the key of the metric name
+	JSONNameKey string
+	// JSONStringFields are the keys of the strings kept as fields
+	JSONStringFields []string
+	// JSONFieldKeys are the keys of the fields kept, all if empty
+	JSONFieldKeys []string
+	// JSONTimeKey is the key of the metric time, parsed with JSONTimeFormat
+	JSONTimeKey    string
+	JSONTimeFormat string
 	// MetricName applies to JSON & value. This will be the name of the measurement.
 	MetricName string
 
@@ -87,6 +102,36 @@ type Config struct {
 	// an optional map containing tag names as keys and json paths to retrieve the tag values from as values
 	// used if TagsPath is empty or doesn't return any tags
 	DropwizardTagPathsMap map[string]string
+
+	// number of header rows holding the column names of csv data
+	CSVHeaderRowCount int
+	// column names of csv data, overriding those of the header
+	CSVColumnNames []string
+	// number of rows skipped before the header, and of columns skipped on
+	// the left of each row
+	CSVSkipRows    int
+	CSVSkipColumns int
+	// column delimiter, "," by default, and character starting comments
+	CSVDelimiter string
+	CSVComment   string
+	// trim the spaces around the values
+	CSVTrimSpace bool
+	// columns of the tags, the measurement name and the timestamp
+	CSVTagColumns        []string
+	CSVMeasurementColumn string
+	CSVTimestampColumn   string
+	// format of the timestamp column, a Go time layout or "unix", "unix_ms",
+	// "unix_us" or "unix_ns"
+	CSVTimestampFormat string
+
+	// grok patterns matched against each line, the first matching one
+	// making the metric
+	GrokPatterns []string
+	// custom patterns defined inline or in pattern files
+	GrokCustomPatterns     string
+	GrokCustomPatternFiles []string
+	// timezone of the timestamps without an offset, UTC by default
+	GrokTimezone string
 }
 
 // NewParser returns a Parser interface based on the given config.
@@ -95,8 +140,7 @@ func NewParser(config *Config) (Parser, error) {
 	var parser Parser
 	switch config.DataFormat {
 	case "json":
-		parser, err = NewJSONParser(config.MetricName,
-			config.TagKeys, config.DefaultTags)
+		parser, err = newJSONParser(config)
 	case "value":
 		parser, err = NewValueParser(config.MetricName,
 			config.DataType, config.DefaultTags)
@@ -120,6 +164,12 @@ func NewParser(config *Config) (Parser, error) {
 			config.DefaultTags,
 			config.Separator,
 			config.Templates)
+	case "csv":
+		parser, err = NewCSVParser(config)
+	case "prometheus":
+		parser, err = NewPrometheusParser(config.DefaultTags)
+	case "grok":
+		parser, err = NewGrokParser(config)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -139,6 +189,27 @@ func NewJSONParser(
 	return parser, nil
 }
 
+func newJSONParser(config *Config) (Parser, error) {
+	if config.JSONTimeKey != "" && config.JSONTimeFormat == "" {
+		return nil, fmt.Errorf("json_time_format must be set with json_time_key")
+	}
+	parser := &json.JSONParser{
+		MetricName:   config.MetricName,
+		TagKeys:      config.TagKeys,
+		DefaultTags:  config.DefaultTags,
+		Query:        config.JSONQuery,
+		NameKey:      config.JSONNameKey,
+		StringFields: config.JSONStringFields,
+		FieldKeys:    config.JSONFieldKeys,
+		TimeKey:      config.JSONTimeKey,
+		TimeFormat:   config.JSONTimeFormat,
+	}
+	if err := parser.Compile(); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
 func NewNagiosParser() (Parser, error) {
 	return &nagios.NagiosParser{}, nil
 }
@@ -200,3 +271,40 @@ func NewDropwizardParser(
 	}
 	return parser, err
 }
+
+func NewPrometheusParser(defaultTags map[string]string) (Parser, error) {
+	return &prometheus.Parser{DefaultTags: defaultTags}, nil
+}
+
+func NewCSVParser(config *Config) (Parser, error) {
+	return csv.NewParser(&csv.Parser{
+		MetricName:        config.MetricName,
+		HeaderRowCount:    config.CSVHeaderRowCount,
+		ColumnNames:       config.CSVColumnNames,
+		SkipRows:          config.CSVSkipRows,
+		SkipColumns:       config.CSVSkipColumns,
+		Delimiter:         config.CSVDelimiter,
+		Comment:           config.CSVComment,
+		TrimSpace:         config.CSVTrimSpace,
+		TagColumns:        config.CSVTagColumns,
+		MeasurementColumn: config.CSVMeasurementColumn,
+		TimestampColumn:   config.CSVTimestampColumn,
+		TimestampFormat:   config.CSVTimestampFormat,
+		DefaultTags:       config.DefaultTags,
+	})
+}
+
+func NewGrokParser(config *Config) (Parser, error) {
+	parser := &grok.Parser{
+		Measurement:        config.MetricName,
+		Patterns:           config.GrokPatterns,
+		CustomPatterns:     config.GrokCustomPatterns,
+		CustomPatternFiles: config.GrokCustomPatternFiles,
+		Timezone:           config.GrokTimezone,
+		DefaultTags:        config.DefaultTags,
+	}
+	if err := parser.Compile(); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
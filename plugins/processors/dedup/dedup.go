@@ -0,0 +1,126 @@
+package dedup
+
+import (
+	"sort"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Maximum time a series is suppressed. An unchanged metric is passed on
+  ## once this time elapsed since the series was last passed on.
+  # dedup_interval = "10m"
+
+  ## Maximum number of series remembered. When exceeded, the series passed on
+  ## the longest ago are forgotten.
+  # max_series = 10000
+`
+
+type Dedup struct {
+	DedupInterval internal.Duration `toml:"dedup_interval"`
+	MaxSeries     int               `toml:"max_series"`
+
+	// cache holds the last metric passed on by series.
+	cache map[uint64]telegraf.Metric
+	// latest is the time of the most recent metric, and expired the time
+	// the series older than the interval were last removed.
+	latest  time.Time
+	expired time.Time
+}
+
+func NewDedup() *Dedup {
+	return &Dedup{
+		DedupInterval: internal.Duration{Duration: 10 * time.Minute},
+		MaxSeries:     10000,
+		cache:         make(map[uint64]telegraf.Metric),
+	}
+}
+
+func (d *Dedup) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Dedup) Description() string {
+	return "Drop metrics whose fields did not change since the series was last passed on"
+}
+
+func (d *Dedup) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	out := in[:0]
+	for _, m := range in {
+		if m.Time().After(d.latest) {
+			d.latest = m.Time()
+		}
+
+		id := m.HashID()
+		last, ok := d.cache[id]
+		if ok && m.Time().Before(last.Time()) {
+			// Metrics older than the cached one are passed on without
+			// replacing it.
+			out = append(out, m)
+			continue
+		}
+		if ok && m.Time().Sub(last.Time()) < d.DedupInterval.Duration &&
+			sameFields(last, m) {
+			continue
+		}
+		d.cache[id] = m.Copy()
+		out = append(out, m)
+	}
+	d.expire()
+	return out
+}
+
+// expire removes the series passed on longer than the interval ago, which
+// will be passed on anyway, at most once per interval. If the cache still
+// holds more than max_series series, the oldest are removed.
+func (d *Dedup) expire() {
+	if d.latest.Sub(d.expired) >= d.DedupInterval.Duration {
+		for id, m := range d.cache {
+			if d.latest.Sub(m.Time()) >= d.DedupInterval.Duration {
+				delete(d.cache, id)
+			}
+		}
+		d.expired = d.latest
+	}
+
+	if d.MaxSeries <= 0 || len(d.cache) <= d.MaxSeries {
+		return
+	}
+	// Evict a tenth of the series at once, so that a steady flow of new
+	// series does not require sorting the cache for each of them.
+	ids := make([]uint64, 0, len(d.cache))
+	for id := range d.cache {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return d.cache[ids[i]].Time().Before(d.cache[ids[j]].Time())
+	})
+	evict := len(d.cache) - d.MaxSeries + d.MaxSeries/10
+	for _, id := range ids[:evict] {
+		delete(d.cache, id)
+	}
+}
+
+// sameFields returns true if a and b have the same fields with equal values.
+func sameFields(a, b telegraf.Metric) bool {
+	af, bf := a.FieldList(), b.FieldList()
+	if len(af) != len(bf) {
+		return false
+	}
+	for _, field := range bf {
+		v, ok := a.GetField(field.Key)
+		if !ok || v != field.Value {
+			return false
+		}
+	}
+	return true
+}
+
+func init() {
+	processors.Add("dedup", func() telegraf.Processor {
+		return NewDedup()
+	})
+}
@@ -0,0 +1,133 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestMetric(host string, value interface{}, sec int64) telegraf.Metric {
+	metric, _ := metric.New("sensors",
+		map[string]string{"host": host},
+		map[string]interface{}{"value": value},
+		time.Unix(sec, 0),
+	)
+	return metric
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		message string
+		metrics []telegraf.Metric
+		// expectedPassed is the number of metrics passed on for each metric
+		expectedPassed []int
+	}{
+		{
+			message: "Should suppress unchanged values",
+			metrics: []telegraf.Metric{
+				createTestMetric("a", 1.0, 0),
+				createTestMetric("a", 1.0, 10),
+				createTestMetric("a", 1.0, 20),
+				createTestMetric("a", 2.0, 30),
+				createTestMetric("a", 2.0, 40),
+			},
+			expectedPassed: []int{1, 0, 0, 1, 0},
+		},
+		{
+			message: "Should pass value of another type",
+			metrics: []telegraf.Metric{
+				createTestMetric("a", int64(1), 0),
+				createTestMetric("a", 1.0, 10),
+			},
+			expectedPassed: []int{1, 1},
+		},
+		{
+			message: "Should pass unchanged value after dedup interval of last passed",
+			metrics: []telegraf.Metric{
+				createTestMetric("a", 1.0, 0),
+				createTestMetric("a", 1.0, 50),
+				createTestMetric("a", 1.0, 60),
+				createTestMetric("a", 1.0, 110),
+				createTestMetric("a", 1.0, 120),
+			},
+			expectedPassed: []int{1, 0, 1, 0, 1},
+		},
+		{
+			message: "Should pass out of order values",
+			metrics: []telegraf.Metric{
+				createTestMetric("a", 1.0, 10),
+				createTestMetric("a", 1.0, 5),
+				createTestMetric("a", 1.0, 20),
+			},
+			expectedPassed: []int{1, 1, 0},
+		},
+	}
+
+	for _, test := range tests {
+		dedup := NewDedup()
+		dedup.DedupInterval = internal.Duration{Duration: time.Minute}
+
+		var passed []int
+		for _, m := range test.metrics {
+			passed = append(passed, len(dedup.Apply(m)))
+		}
+
+		assert.Equal(t, test.expectedPassed, passed, test.message)
+	}
+}
+
+func TestAddedField(t *testing.T) {
+	dedup := NewDedup()
+	dedup.DedupInterval = internal.Duration{Duration: time.Minute}
+
+	assert.Len(t, dedup.Apply(createTestMetric("a", 1.0, 0)), 1)
+
+	m := createTestMetric("a", 1.0, 10)
+	m.AddField("status", "ok")
+	assert.Len(t, dedup.Apply(m), 1, "Metric with added field was suppressed")
+}
+
+func TestSeries(t *testing.T) {
+	dedup := NewDedup()
+	dedup.DedupInterval = internal.Duration{Duration: time.Minute}
+
+	processed := dedup.Apply(createTestMetric("a", 1.0, 0), createTestMetric("b", 1.0, 0))
+	assert.Len(t, processed, 2)
+
+	processed = dedup.Apply(createTestMetric("a", 1.0, 10), createTestMetric("b", 2.0, 10))
+	assert.Len(t, processed, 1)
+	host, _ := processed[0].GetTag("host")
+	assert.Equal(t, "b", host, "Only the changed series should be passed on")
+}
+
+func TestExpire(t *testing.T) {
+	dedup := NewDedup()
+	dedup.DedupInterval = internal.Duration{Duration: time.Minute}
+
+	dedup.Apply(createTestMetric("a", 1.0, 0), createTestMetric("b", 1.0, 0))
+	assert.Len(t, dedup.cache, 2)
+
+	dedup.Apply(createTestMetric("a", 2.0, 90))
+	assert.Len(t, dedup.cache, 1, "Series older than the interval were not removed")
+	assert.Contains(t, dedup.cache, createTestMetric("a", 2.0, 90).HashID())
+}
+
+func TestMaxSeries(t *testing.T) {
+	dedup := NewDedup()
+	dedup.DedupInterval = internal.Duration{Duration: time.Minute}
+	dedup.MaxSeries = 10
+
+	for i := 0; i < 11; i++ {
+		dedup.Apply(createTestMetric(string('a'+rune(i)), 1.0, int64(i)))
+	}
+
+	assert.Len(t, dedup.cache, 9, "The two oldest series were not evicted")
+	assert.NotContains(t, dedup.cache, createTestMetric("a", 1.0, 0).HashID())
+	assert.NotContains(t, dedup.cache, createTestMetric("b", 1.0, 0).HashID())
+	assert.Contains(t, dedup.cache, createTestMetric("c", 1.0, 0).HashID())
+	assert.Len(t, dedup.Apply(createTestMetric("a", 1.0, 20)), 1, "Evicted series was suppressed")
+}
@@ -0,0 +1,353 @@
+package enum
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Interval at which the lookup files are checked for changes, and reloaded
+  ## if modified.
+  # reload_interval = "1m"
+
+  [[processors.enum.mapping]]
+    ## Name of the field to map. Set tag instead to map a tag.
+    field = "status"
+    # tag = "status"
+
+    ## Key the mapped value is written to, the mapped key is replaced if
+    ## empty.
+    # dest = "status_code"
+
+    ## Value written for the values without a mapping. They are left
+    ## unchanged if not set.
+    # default = -1
+
+    ## Lookup file of the mappings, a CSV file with the value and its mapping
+    ## on each line, or a JSON object if the file name ends with ".json".
+    ## The mappings of value_mappings take precedence over those of the file.
+    # lookup_file = "/etc/telegraf/status.csv"
+
+    ## Table of mappings. Values are looked up by their string form, so 200
+    ## and "200" both match the key "200". Keys may be glob patterns, such
+    ## as "2??" for the HTTP success statuses, used for the values without
+    ## an exact mapping.
+    [processors.enum.mapping.value_mappings]
+      OK = 0
+      WARNING = 1
+      CRITICAL = 2
+`
+
+type EnumMapper struct {
+	Mappings       []*Mapping        `toml:"mapping"`
+	ReloadInterval internal.Duration `toml:"reload_interval"`
+}
+
+// Mapping maps the values of a field or tag.
+type Mapping struct {
+	Field         string
+	Tag           string
+	Dest          string
+	Default       interface{}
+	ValueMappings map[string]interface{} `toml:"value_mappings"`
+	LookupFile    string                 `toml:"lookup_file"`
+
+	// table holds the mappings of the lookup file and value_mappings, and
+	// patterns those of its keys that are glob patterns. modTime is the
+	// modification time of the lookup file when loaded, and checked the time
+	// it was last checked.
+	table    map[string]interface{}
+	patterns []pattern
+	modTime  time.Time
+	checked  time.Time
+}
+
+// pattern is a mapping whose key is a glob pattern.
+type pattern struct {
+	filter filter.Filter
+	value  interface{}
+}
+
+func NewEnumMapper() *EnumMapper {
+	return &EnumMapper{
+		ReloadInterval: internal.Duration{Duration: time.Minute},
+	}
+}
+
+func (e *EnumMapper) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *EnumMapper) Description() string {
+	return "Map field and tag values through lookup tables"
+}
+
+func (e *EnumMapper) Init() error {
+	for _, m := range e.Mappings {
+		if (m.Field == "") == (m.Tag == "") {
+			return errors.New("mapping must set one of field or tag")
+		}
+		if m.Default != nil {
+			if _, ok := toString(m.Default); !ok {
+				return fmt.Errorf("invalid default %v of mapping %s",
+					m.Default, m.key())
+			}
+		}
+		for k, v := range m.ValueMappings {
+			if _, ok := toString(v); !ok {
+				return fmt.Errorf("invalid value %v of %q in mapping %s",
+					v, k, m.key())
+			}
+		}
+		if err := m.load(time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *EnumMapper) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	now := time.Now()
+	for _, m := range e.Mappings {
+		if m.LookupFile != "" && now.Sub(m.checked) >= e.ReloadInterval.Duration {
+			if err := m.load(now); err != nil {
+				log.Printf("E! [processors.enum] %v", err)
+			}
+		}
+	}
+
+	for _, metric := range in {
+		for _, m := range e.Mappings {
+			m.apply(metric)
+		}
+	}
+	return in
+}
+
+// key returns the name of the field or tag mapped.
+func (m *Mapping) key() string {
+	if m.Tag != "" {
+		return m.Tag
+	}
+	return m.Field
+}
+
+func (m *Mapping) apply(metric telegraf.Metric) {
+	dest := m.Dest
+	if dest == "" {
+		dest = m.key()
+	}
+
+	if m.Tag != "" {
+		value, ok := metric.GetTag(m.Tag)
+		if !ok {
+			return
+		}
+		if mapped, ok := m.lookup(value); ok {
+			tag, _ := toString(mapped)
+			metric.AddTag(dest, tag)
+		}
+		return
+	}
+
+	value, ok := metric.GetField(m.Field)
+	if !ok {
+		return
+	}
+	str, ok := toString(value)
+	if !ok {
+		return
+	}
+	if mapped, ok := m.lookup(str); ok {
+		metric.AddField(dest, mapped)
+	}
+}
+
+// lookup returns the mapping of the value, that of the first pattern
+// matching it if it has none, or the default if no pattern matches.
+func (m *Mapping) lookup(value string) (interface{}, bool) {
+	if mapped, ok := m.table[value]; ok {
+		return mapped, true
+	}
+	for _, p := range m.patterns {
+		if p.filter.Match(value) {
+			return p.value, true
+		}
+	}
+	return m.Default, m.Default != nil
+}
+
+// setTable sets the table of mappings and compiles the keys that are glob
+// patterns, in the order of the keys.
+func (m *Mapping) setTable(table map[string]interface{}) error {
+	var keys []string
+	for k := range table {
+		if strings.ContainsAny(k, "*?[") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	patterns := make([]pattern, 0, len(keys))
+	for _, k := range keys {
+		f, err := filter.Compile([]string{k})
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q in mapping %s: %v",
+				k, m.key(), err)
+		}
+		patterns = append(patterns, pattern{filter: f, value: table[k]})
+	}
+	m.table = table
+	m.patterns = patterns
+	return nil
+}
+
+// load builds the table of mappings, reading the lookup file if it was
+// modified since it was last loaded. The table is left unchanged on error.
+func (m *Mapping) load(now time.Time) error {
+	m.checked = now
+	if m.LookupFile == "" {
+		return m.setTable(m.ValueMappings)
+	}
+
+	info, err := os.Stat(m.LookupFile)
+	if err != nil {
+		return fmt.Errorf("cannot read lookup file of mapping %s: %v",
+			m.key(), err)
+	}
+	if m.table != nil && info.ModTime().Equal(m.modTime) {
+		return nil
+	}
+
+	mappings, err := readLookupFile(m.LookupFile)
+	if err != nil {
+		return fmt.Errorf("cannot read lookup file of mapping %s: %v",
+			m.key(), err)
+	}
+	for k, v := range m.ValueMappings {
+		mappings[k] = v
+	}
+	if err := m.setTable(mappings); err != nil {
+		return err
+	}
+	m.modTime = info.ModTime()
+	return nil
+}
+
+// readLookupFile reads the mappings of a JSON object if the file name ends
+// with ".json", and of a CSV file with a value and its mapping on each line
+// otherwise.
+func readLookupFile(path string) (map[string]interface{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		return readJSON(f)
+	}
+	return readCSV(f)
+}
+
+func readJSON(r io.Reader) (map[string]interface{}, error) {
+	var values map[string]interface{}
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+
+	mappings := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		switch v := v.(type) {
+		case json.Number:
+			if i, err := v.Int64(); err == nil {
+				mappings[k] = i
+			} else if f, err := v.Float64(); err == nil {
+				mappings[k] = f
+			} else {
+				return nil, fmt.Errorf("invalid number %s of %q", v, k)
+			}
+		case string, bool:
+			mappings[k] = v
+		default:
+			return nil, fmt.Errorf("invalid value %v of %q", v, k)
+		}
+	}
+	return mappings, nil
+}
+
+func readCSV(r io.Reader) (map[string]interface{}, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	mappings := make(map[string]interface{})
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return mappings, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		mappings[record[0]] = parseValue(record[1])
+	}
+}
+
+// parseValue returns the integer, float or boolean of a CSV value, or the
+// value itself if it is none of them.
+func parseValue(value string) interface{} {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	switch value {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return value
+}
+
+func toString(v interface{}) (string, bool) {
+	switch value := v.(type) {
+	case int64:
+		return strconv.FormatInt(value, 10), true
+	case uint64:
+		return strconv.FormatUint(value, 10), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(value), true
+	case string:
+		return value, true
+	}
+	return "", false
+}
+
+func init() {
+	processors.Add("enum", func() telegraf.Processor {
+		return NewEnumMapper()
+	})
+}
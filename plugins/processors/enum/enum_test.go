@@ -0,0 +1,254 @@
+package enum
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestMetric(tags map[string]string, fields map[string]interface{}) telegraf.Metric {
+	metric, _ := metric.New("service", tags, fields, time.Unix(0, 0))
+	return metric
+}
+
+func TestFieldConversions(t *testing.T) {
+	patterns := Mapping{
+		Field: "status_code",
+		Dest:  "status_class",
+		ValueMappings: map[string]interface{}{
+			"2??": "success",
+			"5*":  "server_error",
+			"503": "unavailable",
+		},
+	}
+
+	tests := []struct {
+		message        string
+		mapping        Mapping
+		fields         map[string]interface{}
+		expectedFields map[string]interface{}
+	}{
+		{
+			message: "Should map field value",
+			mapping: Mapping{
+				Field:         "status",
+				ValueMappings: map[string]interface{}{"OK": int64(0), "CRITICAL": int64(2)},
+			},
+			fields:         map[string]interface{}{"status": "OK"},
+			expectedFields: map[string]interface{}{"status": int64(0)},
+		},
+		{
+			message: "Should keep unmapped field value",
+			mapping: Mapping{
+				Field:         "status",
+				ValueMappings: map[string]interface{}{"OK": int64(0), "CRITICAL": int64(2)},
+			},
+			fields:         map[string]interface{}{"status": "UNKNOWN"},
+			expectedFields: map[string]interface{}{"status": "UNKNOWN"},
+		},
+		{
+			message: "Should map number to destination field",
+			mapping: Mapping{
+				Field:         "state",
+				Dest:          "state_name",
+				ValueMappings: map[string]interface{}{"1": "stopped", "4": "running"},
+			},
+			fields: map[string]interface{}{"state": int64(4)},
+			expectedFields: map[string]interface{}{
+				"state":      int64(4),
+				"state_name": "running",
+			},
+		},
+		{
+			message: "Should map value matching pattern",
+			mapping: patterns,
+			fields:  map[string]interface{}{"status_code": int64(204)},
+			expectedFields: map[string]interface{}{
+				"status_code":  int64(204),
+				"status_class": "success",
+			},
+		},
+		{
+			message: "Should map value matching wildcard pattern",
+			mapping: patterns,
+			fields:  map[string]interface{}{"status_code": int64(500)},
+			expectedFields: map[string]interface{}{
+				"status_code":  int64(500),
+				"status_class": "server_error",
+			},
+		},
+		{
+			message: "Should prefer exact mapping over pattern",
+			mapping: patterns,
+			fields:  map[string]interface{}{"status_code": int64(503)},
+			expectedFields: map[string]interface{}{
+				"status_code":  int64(503),
+				"status_class": "unavailable",
+			},
+		},
+		{
+			message:        "Should not map value matching no pattern",
+			mapping:        patterns,
+			fields:         map[string]interface{}{"status_code": int64(2000)},
+			expectedFields: map[string]interface{}{"status_code": int64(2000)},
+		},
+	}
+
+	for _, test := range tests {
+		mapping := test.mapping
+		mapper := NewEnumMapper()
+		mapper.Mappings = []*Mapping{&mapping}
+		require.NoError(t, mapper.Init(), test.message)
+
+		processed := mapper.Apply(createTestMetric(nil, test.fields))
+
+		require.Len(t, processed, 1, test.message)
+		assert.Equal(t, test.expectedFields, processed[0].Fields(), test.message)
+		assert.Equal(t, "service", processed[0].Name(), "Should not change name")
+	}
+}
+
+func TestTagConversions(t *testing.T) {
+	tests := []struct {
+		message      string
+		tags         map[string]string
+		expectedTags map[string]string
+	}{
+		{
+			message:      "Should map tag value",
+			tags:         map[string]string{"status": "UP"},
+			expectedTags: map[string]string{"status": "UP", "status_code": "1"},
+		},
+		{
+			message:      "Should use default for unmapped tag value",
+			tags:         map[string]string{"status": "MAINT"},
+			expectedTags: map[string]string{"status": "MAINT", "status_code": "-1"},
+		},
+		{
+			message:      "Should not add tag if the tag is missing",
+			tags:         map[string]string{},
+			expectedTags: map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		mapper := NewEnumMapper()
+		mapper.Mappings = []*Mapping{{
+			Tag:           "status",
+			Dest:          "status_code",
+			Default:       int64(-1),
+			ValueMappings: map[string]interface{}{"UP": int64(1)},
+		}}
+		require.NoError(t, mapper.Init(), test.message)
+
+		processed := mapper.Apply(createTestMetric(test.tags, map[string]interface{}{"value": 1}))
+
+		require.Len(t, processed, 1, test.message)
+		assert.Equal(t, test.expectedTags, processed[0].Tags(), test.message)
+		assert.Equal(t, map[string]interface{}{"value": int64(1)}, processed[0].Fields(), "Should not change fields")
+	}
+}
+
+func TestInitInvalid(t *testing.T) {
+	tests := []struct {
+		message string
+		mapping Mapping
+	}{
+		{
+			message: "Should reject both field and tag",
+			mapping: Mapping{Field: "status", Tag: "status"},
+		},
+		{
+			message: "Should reject invalid default",
+			mapping: Mapping{Field: "status", Default: []interface{}{}},
+		},
+		{
+			message: "Should reject missing lookup file",
+			mapping: Mapping{Field: "status", LookupFile: "/nonexistent.csv"},
+		},
+		{
+			message: "Should reject invalid pattern",
+			mapping: Mapping{
+				Field:         "status",
+				ValueMappings: map[string]interface{}{"[2": "success"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		mapping := test.mapping
+		mapper := NewEnumMapper()
+		mapper.Mappings = []*Mapping{&mapping}
+		assert.Error(t, mapper.Init(), test.message)
+	}
+}
+
+func TestLookupFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "enum")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	csvFile := filepath.Join(dir, "status.csv")
+	require.NoError(t, ioutil.WriteFile(csvFile,
+		[]byte("# status,code\nOK,0\nWARNING, 1\nCRITICAL,2.5\nUNKNOWN,unknown\n"), 0644))
+	jsonFile := filepath.Join(dir, "status.json")
+	require.NoError(t, ioutil.WriteFile(jsonFile,
+		[]byte(`{"OK": 0, "WARNING": 1.5, "CRITICAL": "critical", "UNKNOWN": true}`), 0644))
+
+	mapper := NewEnumMapper()
+	mapper.Mappings = []*Mapping{
+		{
+			Field:         "status",
+			Dest:          "csv",
+			LookupFile:    csvFile,
+			ValueMappings: map[string]interface{}{"OK": int64(10)},
+		},
+		{Field: "status", Dest: "json", LookupFile: jsonFile},
+	}
+	require.NoError(t, mapper.Init())
+
+	var fields []map[string]interface{}
+	for _, status := range []string{"OK", "WARNING", "CRITICAL", "UNKNOWN"} {
+		processed := mapper.Apply(createTestMetric(nil, map[string]interface{}{"status": status}))
+		fields = append(fields, processed[0].Fields())
+	}
+	assert.Equal(t, []map[string]interface{}{
+		{"status": "OK", "csv": int64(10), "json": int64(0)},
+		{"status": "WARNING", "csv": int64(1), "json": 1.5},
+		{"status": "CRITICAL", "csv": 2.5, "json": "critical"},
+		{"status": "UNKNOWN", "csv": "unknown", "json": true},
+	}, fields)
+}
+
+func TestLookupFileReload(t *testing.T) {
+	f, err := ioutil.TempFile("", "enum")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("OK,0\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	mapper := NewEnumMapper()
+	mapper.Mappings = []*Mapping{{Field: "status", LookupFile: f.Name()}}
+	require.NoError(t, mapper.Init())
+	mapper.ReloadInterval.Duration = 0
+	m := mapper.Mappings[0]
+
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte("OK,1\n"), 0644))
+	modTime := m.modTime.Add(time.Second)
+	require.NoError(t, os.Chtimes(f.Name(), modTime, modTime))
+	processed := mapper.Apply(createTestMetric(nil, map[string]interface{}{"status": "OK"}))
+	assert.Equal(t, map[string]interface{}{"status": int64(1)}, processed[0].Fields())
+
+	require.NoError(t, os.Remove(f.Name()))
+	processed = mapper.Apply(createTestMetric(nil, map[string]interface{}{"status": "OK"}))
+	assert.Equal(t, map[string]interface{}{"status": int64(1)}, processed[0].Fields(),
+		"Mappings were not kept when the file cannot be read")
+}
@@ -0,0 +1,258 @@
+package rate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Fields of the counters, accepts globs. All the numeric fields are used
+  ## if empty.
+  # fields = []
+
+  ## "rate" computes the change per second, "delta" the change since the
+  ## previous value.
+  # mode = "rate"
+
+  ## Suffix appended to the counter field names for the computed fields.
+  # suffix = "_rate"
+
+  ## Remove the counter fields, keeping only the computed fields. Metrics left
+  ## without fields are dropped.
+  # drop_original = false
+
+  ## Maximum time between two values of a series. When exceeded, no value is
+  ## computed and the series starts over. 0 disables the limit.
+  # max_gap = "0s"
+
+  ## Maximum value of the counters before they wrap around to zero, such as
+  ## 4294967295 for 32-bit counters. When 0, a decreasing counter is
+  ## considered reset and no value is computed.
+  # counter_max = 0
+`
+
+const (
+	modeRate  = "rate"
+	modeDelta = "delta"
+)
+
+type Rate struct {
+	Fields       []string
+	Mode         string
+	Suffix       string
+	DropOriginal bool              `toml:"drop_original"`
+	MaxGap       internal.Duration `toml:"max_gap"`
+	CounterMax   uint64            `toml:"counter_max"`
+
+	fieldFilter filter.Filter
+	// series holds the previous values of the counters by series and
+	// field.
+	series map[uint64]map[string]sample
+	// latest is the time of the most recent metric, and pruned the time
+	// the series not updated within max_gap were last removed.
+	latest time.Time
+	pruned time.Time
+}
+
+// sample is a value of a counter.
+type sample struct {
+	value interface{}
+	time  time.Time
+}
+
+func NewRate() *Rate {
+	return &Rate{
+		Mode:   modeRate,
+		Suffix: "_rate",
+		series: make(map[uint64]map[string]sample),
+	}
+}
+
+func (r *Rate) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Rate) Description() string {
+	return "Compute the rate or delta of counter fields"
+}
+
+func (r *Rate) Init() error {
+	if r.Mode != modeRate && r.Mode != modeDelta {
+		return fmt.Errorf("invalid mode %q, must be %q or %q", r.Mode,
+			modeRate, modeDelta)
+	}
+	if r.Suffix == "" && !r.DropOriginal {
+		return errors.New("suffix must be set unless drop_original is enabled")
+	}
+
+	var err error
+	r.fieldFilter, err = filter.Compile(r.Fields)
+	return err
+}
+
+func (r *Rate) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	out := in[:0]
+	for _, m := range in {
+		if r.apply(m) {
+			out = append(out, m)
+		}
+	}
+	r.prune()
+	return out
+}
+
+// apply adds the computed fields to m, returning false if m is left without
+// fields and must be dropped.
+func (r *Rate) apply(m telegraf.Metric) bool {
+	id := m.HashID()
+	prev := r.series[id]
+	if m.Time().After(r.latest) {
+		r.latest = m.Time()
+	}
+
+	var counters []string
+	computed := make(map[string]interface{})
+	for _, field := range m.FieldList() {
+		if r.fieldFilter != nil && !r.fieldFilter.Match(field.Key) {
+			continue
+		}
+		switch field.Value.(type) {
+		case int64, uint64, float64:
+		default:
+			continue
+		}
+		counters = append(counters, field.Key)
+
+		cur := sample{value: field.Value, time: m.Time()}
+		last, ok := prev[field.Key]
+		if ok && !cur.time.After(last.time) {
+			// Out of order or duplicate values are ignored.
+			continue
+		}
+		if prev == nil {
+			prev = make(map[string]sample)
+			r.series[id] = prev
+		}
+		prev[field.Key] = cur
+		if !ok {
+			continue
+		}
+		elapsed := cur.time.Sub(last.time)
+		if r.MaxGap.Duration > 0 && elapsed > r.MaxGap.Duration {
+			continue
+		}
+
+		delta, ok := r.delta(last.value, cur.value)
+		if !ok {
+			continue
+		}
+		if r.Mode == modeRate {
+			computed[field.Key+r.Suffix] = toFloat(delta) / elapsed.Seconds()
+		} else {
+			computed[field.Key+r.Suffix] = delta
+		}
+	}
+
+	if r.DropOriginal {
+		for _, key := range counters {
+			m.RemoveField(key)
+		}
+	}
+	for key, value := range computed {
+		m.AddField(key, value)
+	}
+	return len(m.FieldList()) > 0
+}
+
+// delta returns the increase of a counter from prev to cur, in the type of
+// the counter. It returns false if the counter was reset or changed type.
+func (r *Rate) delta(prev, cur interface{}) (interface{}, bool) {
+	switch cur := cur.(type) {
+	case int64:
+		prev, ok := prev.(int64)
+		if !ok {
+			return nil, false
+		}
+		if cur >= prev {
+			return cur - prev, true
+		}
+		if r.CounterMax == 0 || prev < 0 || cur < 0 ||
+			uint64(prev) > r.CounterMax {
+			return nil, false
+		}
+		delta := r.CounterMax - uint64(prev) + uint64(cur) + 1
+		if delta > math.MaxInt64 {
+			return nil, false
+		}
+		return int64(delta), true
+	case uint64:
+		prev, ok := prev.(uint64)
+		if !ok {
+			return nil, false
+		}
+		if cur >= prev {
+			return cur - prev, true
+		}
+		if r.CounterMax == 0 || prev > r.CounterMax {
+			return nil, false
+		}
+		return r.CounterMax - prev + cur + 1, true
+	case float64:
+		prev, ok := prev.(float64)
+		if !ok {
+			return nil, false
+		}
+		if cur >= prev {
+			return cur - prev, true
+		}
+		if r.CounterMax == 0 || prev > float64(r.CounterMax) {
+			return nil, false
+		}
+		return float64(r.CounterMax) - prev + cur + 1, true
+	}
+	return nil, false
+}
+
+// prune removes the series not updated within max_gap of the most recent
+// metric, at most once per max_gap.
+func (r *Rate) prune() {
+	if r.MaxGap.Duration <= 0 || r.latest.Sub(r.pruned) < r.MaxGap.Duration {
+		return
+	}
+	for id, fields := range r.series {
+		for key, s := range fields {
+			if r.latest.Sub(s.time) > r.MaxGap.Duration {
+				delete(fields, key)
+			}
+		}
+		if len(fields) == 0 {
+			delete(r.series, id)
+		}
+	}
+	r.pruned = r.latest
+}
+
+func toFloat(v interface{}) float64 {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+func init() {
+	processors.Add("rate", func() telegraf.Processor {
+		return NewRate()
+	})
+}
@@ -0,0 +1,260 @@
+package rate
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestMetric(host string, fields map[string]interface{}, sec int64) telegraf.Metric {
+	metric, _ := metric.New("net",
+		map[string]string{"host": host},
+		fields,
+		time.Unix(sec, 0),
+	)
+	return metric
+}
+
+func TestComputedFields(t *testing.T) {
+	tests := []struct {
+		message        string
+		mode           string
+		suffix         string
+		fields         []string
+		counterMax     uint64
+		metrics        []telegraf.Metric
+		expectedFields map[string]interface{}
+	}{
+		{
+			message: "Should not compute rate of first value",
+			mode:    "rate",
+			suffix:  "_rate",
+			metrics: []telegraf.Metric{
+				createTestMetric("a", map[string]interface{}{"bytes": int64(100)}, 0),
+			},
+			expectedFields: map[string]interface{}{"bytes": int64(100)},
+		},
+		{
+			message: "Should compute rate",
+			mode:    "rate",
+			suffix:  "_rate",
+			metrics: []telegraf.Metric{
+				createTestMetric("a", map[string]interface{}{"bytes": int64(100)}, 0),
+				createTestMetric("a", map[string]interface{}{"bytes": int64(300)}, 10),
+			},
+			expectedFields: map[string]interface{}{
+				"bytes":      int64(300),
+				"bytes_rate": 20.0,
+			},
+		},
+		{
+			message: "Should compute delta of the selected fields",
+			mode:    "delta",
+			suffix:  "_delta",
+			fields:  []string{"bytes", "uptime"},
+			metrics: []telegraf.Metric{
+				createTestMetric("a", map[string]interface{}{
+					"bytes":   uint64(100),
+					"packets": int64(1),
+					"uptime":  1.5,
+				}, 0),
+				createTestMetric("a", map[string]interface{}{
+					"bytes":   uint64(150),
+					"packets": int64(2),
+					"uptime":  3.0,
+				}, 10),
+			},
+			expectedFields: map[string]interface{}{
+				"bytes":        uint64(150),
+				"bytes_delta":  uint64(50),
+				"packets":      int64(2),
+				"uptime":       3.0,
+				"uptime_delta": 1.5,
+			},
+		},
+		{
+			message: "Should not compute delta of reset counter",
+			mode:    "delta",
+			suffix:  "_rate",
+			metrics: []telegraf.Metric{
+				createTestMetric("a", map[string]interface{}{"bytes": int64(100)}, 0),
+				createTestMetric("a", map[string]interface{}{"bytes": int64(10)}, 10),
+			},
+			expectedFields: map[string]interface{}{"bytes": int64(10)},
+		},
+		{
+			message: "Should compute delta after reset",
+			mode:    "delta",
+			suffix:  "_rate",
+			metrics: []telegraf.Metric{
+				createTestMetric("a", map[string]interface{}{"bytes": int64(100)}, 0),
+				createTestMetric("a", map[string]interface{}{"bytes": int64(10)}, 10),
+				createTestMetric("a", map[string]interface{}{"bytes": int64(30)}, 20),
+			},
+			expectedFields: map[string]interface{}{
+				"bytes":      int64(30),
+				"bytes_rate": int64(20),
+			},
+		},
+		{
+			message:    "Should compute delta of wrapped counters",
+			mode:       "delta",
+			suffix:     "_rate",
+			counterMax: 1<<32 - 1,
+			metrics: []telegraf.Metric{
+				createTestMetric("a", map[string]interface{}{
+					"in":  uint64(1<<32 - 10),
+					"out": int64(1<<32 - 1),
+				}, 0),
+				createTestMetric("a", map[string]interface{}{
+					"in":  uint64(5),
+					"out": int64(0),
+				}, 10),
+			},
+			expectedFields: map[string]interface{}{
+				"in":       uint64(5),
+				"in_rate":  uint64(15),
+				"out":      int64(0),
+				"out_rate": int64(1),
+			},
+		},
+		{
+			message:    "Should not compute int64 delta of wrap overflowing int64",
+			mode:       "delta",
+			suffix:     "_rate",
+			counterMax: math.MaxUint64,
+			metrics: []telegraf.Metric{
+				createTestMetric("a", map[string]interface{}{"bytes": int64(100)}, 0),
+				createTestMetric("a", map[string]interface{}{"bytes": int64(10)}, 10),
+			},
+			expectedFields: map[string]interface{}{"bytes": int64(10)},
+		},
+		{
+			message:    "Should consider negative counter reset rather than wrap",
+			mode:       "delta",
+			suffix:     "_rate",
+			counterMax: 1<<32 - 1,
+			metrics: []telegraf.Metric{
+				createTestMetric("a", map[string]interface{}{"bytes": int64(100)}, 0),
+				createTestMetric("a", map[string]interface{}{"bytes": int64(-5)}, 10),
+			},
+			expectedFields: map[string]interface{}{"bytes": int64(-5)},
+		},
+		{
+			message: "Should not compute rate of out of order value",
+			mode:    "rate",
+			suffix:  "_rate",
+			metrics: []telegraf.Metric{
+				createTestMetric("a", map[string]interface{}{"bytes": int64(100)}, 10),
+				createTestMetric("a", map[string]interface{}{"bytes": int64(50)}, 5),
+			},
+			expectedFields: map[string]interface{}{"bytes": int64(50)},
+		},
+		{
+			message: "Should compute rate from last in order value",
+			mode:    "rate",
+			suffix:  "_rate",
+			metrics: []telegraf.Metric{
+				createTestMetric("a", map[string]interface{}{"bytes": int64(100)}, 10),
+				createTestMetric("a", map[string]interface{}{"bytes": int64(50)}, 5),
+				createTestMetric("a", map[string]interface{}{"bytes": int64(200)}, 20),
+			},
+			expectedFields: map[string]interface{}{
+				"bytes":      int64(200),
+				"bytes_rate": 10.0,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		rate := NewRate()
+		rate.Mode = test.mode
+		rate.Suffix = test.suffix
+		rate.Fields = test.fields
+		rate.CounterMax = test.counterMax
+		require.NoError(t, rate.Init(), test.message)
+
+		var processed []telegraf.Metric
+		for _, m := range test.metrics {
+			processed = rate.Apply(m)
+		}
+
+		require.Len(t, processed, 1, test.message)
+		assert.Equal(t, test.expectedFields, processed[0].Fields(), test.message)
+		assert.Equal(t, "net", processed[0].Name(), "Should not change name")
+	}
+}
+
+func TestRateSeries(t *testing.T) {
+	rate := NewRate()
+	require.NoError(t, rate.Init())
+
+	rate.Apply(
+		createTestMetric("a", map[string]interface{}{"bytes": int64(100)}, 0),
+		createTestMetric("b", map[string]interface{}{"bytes": int64(1000)}, 0),
+	)
+	processed := rate.Apply(
+		createTestMetric("a", map[string]interface{}{"bytes": int64(110)}, 10),
+		createTestMetric("b", map[string]interface{}{"bytes": int64(2000)}, 10),
+	)
+
+	require.Len(t, processed, 2)
+	assert.Equal(t, 1.0, processed[0].Fields()["bytes_rate"])
+	assert.Equal(t, 100.0, processed[1].Fields()["bytes_rate"])
+}
+
+func TestMaxGap(t *testing.T) {
+	rate := NewRate()
+	rate.MaxGap = internal.Duration{Duration: time.Minute}
+	require.NoError(t, rate.Init())
+
+	rate.Apply(createTestMetric("a", map[string]interface{}{"bytes": int64(100)}, 0))
+	rate.Apply(createTestMetric("b", map[string]interface{}{"bytes": int64(100)}, 0))
+	processed := rate.Apply(createTestMetric("a", map[string]interface{}{"bytes": int64(200)}, 120))
+	assert.False(t, processed[0].HasField("bytes_rate"), "Should start over after max_gap")
+	assert.Len(t, rate.series, 1, "Series not updated within max_gap was not removed")
+
+	processed = rate.Apply(createTestMetric("a", map[string]interface{}{"bytes": int64(260)}, 180))
+	assert.Equal(t, 1.0, processed[0].Fields()["bytes_rate"])
+}
+
+func TestDropOriginal(t *testing.T) {
+	rate := NewRate()
+	rate.Suffix = ""
+	rate.DropOriginal = true
+	require.NoError(t, rate.Init())
+
+	processed := rate.Apply(createTestMetric("a", map[string]interface{}{
+		"bytes": int64(100),
+		"state": "up",
+	}, 0))
+	require.Len(t, processed, 1)
+	assert.Equal(t, map[string]interface{}{"state": "up"}, processed[0].Fields())
+
+	processed = rate.Apply(createTestMetric("a", map[string]interface{}{"bytes": int64(100)}, 10))
+	require.Len(t, processed, 1)
+	assert.Equal(t, map[string]interface{}{"bytes": 0.0}, processed[0].Fields())
+
+	processed = rate.Apply(createTestMetric("b", map[string]interface{}{"bytes": int64(100)}, 10))
+	assert.Len(t, processed, 0, "Metric left without fields was not dropped")
+}
+
+func TestInitErrors(t *testing.T) {
+	rate := NewRate()
+	rate.Mode = "derivative"
+	assert.Error(t, rate.Init(), "Should reject unknown mode")
+
+	rate = NewRate()
+	rate.Suffix = ""
+	assert.Error(t, rate.Init(), "Should require suffix unless drop_original")
+
+	rate = NewRate()
+	rate.Fields = []string{"a["}
+	assert.Error(t, rate.Init(), "Should reject invalid glob")
+}
@@ -0,0 +1,218 @@
+package script
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"go.starlark.net/starlark"
+)
+
+// Metric is the script representation of a telegraf.Metric. Its tags and
+// fields are dictionaries that the script modifies in place, the metric is
+// converted back once the script returns.
+type Metric struct {
+	name   string
+	tags   *starlark.Dict
+	fields *starlark.Dict
+	time   int64
+	tp     telegraf.ValueType
+	frozen bool
+}
+
+var _ starlark.HasSetField = (*Metric)(nil)
+
+// newMetric returns the script representation of m.
+func newMetric(m telegraf.Metric) (*Metric, error) {
+	sm := &Metric{
+		name:   m.Name(),
+		tags:   starlark.NewDict(len(m.TagList())),
+		fields: starlark.NewDict(len(m.FieldList())),
+		time:   m.Time().UnixNano(),
+		tp:     m.Type(),
+	}
+	for _, tag := range m.TagList() {
+		sm.tags.SetKey(starlark.String(tag.Key), starlark.String(tag.Value))
+	}
+	for _, field := range m.FieldList() {
+		v, err := toValue(field.Value)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %s", field.Key, err)
+		}
+		sm.fields.SetKey(starlark.String(field.Key), v)
+	}
+	return sm, nil
+}
+
+// toMetric converts the script representation back to a telegraf.Metric.
+func (m *Metric) toMetric() (telegraf.Metric, error) {
+	tags := make(map[string]string, m.tags.Len())
+	for _, item := range m.tags.Items() {
+		k, ok := starlark.AsString(item[0])
+		if !ok {
+			return nil, fmt.Errorf("tag key %s is not a string", item[0])
+		}
+		v, ok := starlark.AsString(item[1])
+		if !ok {
+			return nil, fmt.Errorf("tag %s: value %s is not a string", k, item[1])
+		}
+		tags[k] = v
+	}
+
+	fields := make(map[string]interface{}, m.fields.Len())
+	for _, item := range m.fields.Items() {
+		k, ok := starlark.AsString(item[0])
+		if !ok {
+			return nil, fmt.Errorf("field key %s is not a string", item[0])
+		}
+		v, err := fromValue(item[1])
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %s", k, err)
+		}
+		fields[k] = v
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("metric has no fields")
+	}
+
+	return metric.New(m.name, tags, fields, time.Unix(0, m.time), m.tp)
+}
+
+// copy returns a deep copy of m, which is not frozen.
+func (m *Metric) copy() *Metric {
+	c := &Metric{
+		name:   m.name,
+		tags:   starlark.NewDict(m.tags.Len()),
+		fields: starlark.NewDict(m.fields.Len()),
+		time:   m.time,
+		tp:     m.tp,
+	}
+	for _, item := range m.tags.Items() {
+		c.tags.SetKey(item[0], item[1])
+	}
+	for _, item := range m.fields.Items() {
+		c.fields.SetKey(item[0], item[1])
+	}
+	return c
+}
+
+func (m *Metric) String() string {
+	return fmt.Sprintf("Metric(%q, tags=%s, fields=%s, time=%d)",
+		m.name, m.tags, m.fields, m.time)
+}
+
+func (m *Metric) Type() string { return "Metric" }
+
+func (m *Metric) Freeze() {
+	if m.frozen {
+		return
+	}
+	m.frozen = true
+	m.tags.Freeze()
+	m.fields.Freeze()
+}
+
+func (m *Metric) Truth() starlark.Bool { return starlark.True }
+
+func (m *Metric) Hash() (uint32, error) {
+	return 0, errors.New("unhashable type: Metric")
+}
+
+func (m *Metric) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "name":
+		return starlark.String(m.name), nil
+	case "tags":
+		return m.tags, nil
+	case "fields":
+		return m.fields, nil
+	case "time":
+		return starlark.MakeInt64(m.time), nil
+	}
+	return nil, nil
+}
+
+func (m *Metric) AttrNames() []string {
+	return []string{"fields", "name", "tags", "time"}
+}
+
+func (m *Metric) SetField(name string, v starlark.Value) error {
+	if m.frozen {
+		return errors.New("cannot modify frozen Metric")
+	}
+	switch name {
+	case "name":
+		s, ok := v.(starlark.String)
+		if !ok || s == "" {
+			return fmt.Errorf("name must be a non-empty string, got %s", v.Type())
+		}
+		m.name = string(s)
+	case "tags", "fields":
+		d, ok := v.(*starlark.Dict)
+		if !ok {
+			return fmt.Errorf("%s must be a dict, got %s", name, v.Type())
+		}
+		if name == "tags" {
+			m.tags = d
+		} else {
+			m.fields = d
+		}
+	case "time":
+		i, ok := v.(starlark.Int)
+		if !ok {
+			return fmt.Errorf("time must be an int, got %s", v.Type())
+		}
+		ns, ok := i.Int64()
+		if !ok {
+			return fmt.Errorf("time %s out of range", i)
+		}
+		m.time = ns
+	default:
+		return starlark.NoSuchAttrError(
+			fmt.Sprintf("Metric has no field %q, use one of %s", name,
+				strings.Join(m.AttrNames(), ", ")))
+	}
+	return nil
+}
+
+// toValue converts a field value to a script value.
+func toValue(v interface{}) (starlark.Value, error) {
+	switch v := v.(type) {
+	case int64:
+		return starlark.MakeInt64(v), nil
+	case uint64:
+		return starlark.MakeUint64(v), nil
+	case float64:
+		return starlark.Float(v), nil
+	case string:
+		return starlark.String(v), nil
+	case bool:
+		return starlark.Bool(v), nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", v)
+}
+
+// fromValue converts a script value to a field value. Integers are converted
+// to int64, or to uint64 if they are too large.
+func fromValue(v starlark.Value) (interface{}, error) {
+	switch v := v.(type) {
+	case starlark.Int:
+		if i, ok := v.Int64(); ok {
+			return i, nil
+		}
+		if u, ok := v.Uint64(); ok {
+			return u, nil
+		}
+		return nil, fmt.Errorf("integer %s out of range", v)
+	case starlark.Float:
+		return float64(v), nil
+	case starlark.String:
+		return string(v), nil
+	case starlark.Bool:
+		return bool(v), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
@@ -0,0 +1,227 @@
+package script
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"go.starlark.net/starlark"
+)
+
+const sampleConfig = `
+  ## The script, written in Starlark, a dialect of Python. It must define an
+  ## apply function taking a metric and returning the metric, a list of
+  ## metrics, or None to drop it. Either source or script must be set.
+  source = '''
+def apply(metric):
+    return metric
+'''
+
+  ## File containing the script.
+  # script = "/usr/local/share/telegraf/script.star"
+
+  ## Maximum number of execution steps of each call of the apply function.
+  ## The metric is passed on unchanged if the limit is reached, 0 removes the
+  ## limit.
+  # max_steps = 100000
+`
+
+// defaultMaxSteps is the default limit of execution steps of a call.
+const defaultMaxSteps = 100000
+
+// currentTimeKey is the thread local holding the time of the metric being
+// processed, the default time of the metrics created by the script.
+const currentTimeKey = "current_time"
+
+type Script struct {
+	Source   string
+	Script   string
+	MaxSteps uint64
+
+	apply *starlark.Function
+}
+
+func NewScript() *Script {
+	return &Script{
+		MaxSteps: defaultMaxSteps,
+	}
+}
+
+func (s *Script) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Script) Description() string {
+	return "Process metrics using a Starlark script"
+}
+
+// Init compiles and runs the script, so that syntax errors are reported when
+// the configuration is loaded.
+func (s *Script) Init() error {
+	if (s.Source == "") == (s.Script == "") {
+		return errors.New("exactly one of source and script must be set")
+	}
+
+	filename := "script.star"
+	var src interface{} = s.Source
+	if s.Script != "" {
+		b, err := ioutil.ReadFile(s.Script)
+		if err != nil {
+			return err
+		}
+		filename, src = s.Script, b
+	}
+
+	predeclared := starlark.StringDict{
+		"Metric":   starlark.NewBuiltin("Metric", newMetricBuiltin),
+		"deepcopy": starlark.NewBuiltin("deepcopy", deepcopyBuiltin),
+		// state is not frozen, so it keeps values between calls.
+		"state": starlark.NewDict(0),
+	}
+
+	globals, err := starlark.ExecFile(s.newThread(0), filename, src, predeclared)
+	if err != nil {
+		if err, ok := err.(*starlark.EvalError); ok {
+			return errors.New(err.Backtrace())
+		}
+		return err
+	}
+
+	apply, ok := globals["apply"].(*starlark.Function)
+	if !ok {
+		return errors.New("script must define an apply function")
+	}
+	if apply.NumParams() != 1 {
+		return fmt.Errorf("apply function must take one argument, takes %d",
+			apply.NumParams())
+	}
+	s.apply = apply
+	return nil
+}
+
+func (s *Script) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	out := make([]telegraf.Metric, 0, len(in))
+	for _, m := range in {
+		metrics, err := s.applyMetric(m)
+		if err != nil {
+			log.Printf("E! [processors.script] Error processing metric %s: %s",
+				m.Name(), err)
+			out = append(out, m)
+			continue
+		}
+		out = append(out, metrics...)
+	}
+	return out
+}
+
+// applyMetric calls the apply function of the script with m and returns the
+// resulting metrics.
+func (s *Script) applyMetric(m telegraf.Metric) ([]telegraf.Metric, error) {
+	sm, err := newMetric(m)
+	if err != nil {
+		return nil, err
+	}
+
+	thread := s.newThread(sm.time)
+	rv, err := starlark.Call(thread, s.apply, starlark.Tuple{sm}, nil)
+	if err != nil {
+		if err, ok := err.(*starlark.EvalError); ok {
+			return nil, errors.New(err.Backtrace())
+		}
+		return nil, err
+	}
+
+	var values []starlark.Value
+	switch rv := rv.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case *Metric:
+		values = []starlark.Value{rv}
+	case *starlark.List:
+		for i := 0; i < rv.Len(); i++ {
+			values = append(values, rv.Index(i))
+		}
+	case starlark.Tuple:
+		values = rv
+	default:
+		return nil, fmt.Errorf("apply returned %s, expected Metric, list "+
+			"or None", rv.Type())
+	}
+
+	metrics := make([]telegraf.Metric, 0, len(values))
+	for _, v := range values {
+		sm, ok := v.(*Metric)
+		if !ok {
+			return nil, fmt.Errorf("apply returned a list containing %s, "+
+				"expected Metric", v.Type())
+		}
+		result, err := sm.toMetric()
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, result)
+	}
+	return metrics, nil
+}
+
+// newThread returns a thread for running the script, limited to MaxSteps
+// execution steps. now is the default time of the metrics it creates.
+func (s *Script) newThread(now int64) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: "processors.script",
+		Print: func(_ *starlark.Thread, msg string) {
+			log.Printf("I! [processors.script] %s", msg)
+		},
+	}
+	thread.SetMaxExecutionSteps(s.MaxSteps)
+	thread.SetLocal(currentTimeKey, now)
+	return thread
+}
+
+// newMetricBuiltin implements Metric(name), creating a metric without tags
+// and fields at the time of the metric being processed.
+func newMetricBuiltin(
+	thread *starlark.Thread,
+	b *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var name starlark.String
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &name); err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%s: name must not be empty", b.Name())
+	}
+	now, _ := thread.Local(currentTimeKey).(int64)
+	return &Metric{
+		name:   string(name),
+		tags:   starlark.NewDict(0),
+		fields: starlark.NewDict(0),
+		time:   now,
+		tp:     telegraf.Untyped,
+	}, nil
+}
+
+// deepcopyBuiltin implements deepcopy(metric).
+func deepcopyBuiltin(
+	thread *starlark.Thread,
+	b *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var m *Metric
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &m); err != nil {
+		return nil, err
+	}
+	return m.copy(), nil
+}
+
+func init() {
+	processors.Add("script", func() telegraf.Processor {
+		return NewScript()
+	})
+}
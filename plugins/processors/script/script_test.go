@@ -0,0 +1,212 @@
+package script
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestMetric() telegraf.Metric {
+	metric, _ := metric.New("cpu",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{"usage": 42.0, "count": int64(3)},
+		time.Unix(0, 0),
+	)
+	return metric
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		message        string
+		source         string
+		expectedName   string
+		expectedTags   map[string]string
+		expectedFields map[string]interface{}
+		expectedTime   time.Time
+	}{
+		{
+			message: "Should pass metric through",
+			source: `
+def apply(metric):
+    return metric
+`,
+			expectedName:   "cpu",
+			expectedTags:   map[string]string{"host": "localhost"},
+			expectedFields: map[string]interface{}{"usage": 42.0, "count": int64(3)},
+			expectedTime:   time.Unix(0, 0),
+		},
+		{
+			message: "Should modify metric",
+			source: `
+def apply(metric):
+    metric.name = "processor"
+    metric.tags["host"] = metric.tags["host"].upper()
+    metric.tags["dc"] = "east"
+    metric.fields["usage"] = metric.fields["usage"] / 100
+    metric.fields.pop("count")
+    metric.time = metric.time + 1000
+    return metric
+`,
+			expectedName:   "processor",
+			expectedTags:   map[string]string{"host": "LOCALHOST", "dc": "east"},
+			expectedFields: map[string]interface{}{"usage": 0.42},
+			expectedTime:   time.Unix(0, 1000),
+		},
+		{
+			message: "Should pass metric unchanged on runtime error",
+			source: `
+def apply(metric):
+    metric.name = "changed"
+    return metric.fields["missing"]
+`,
+			expectedName:   "cpu",
+			expectedTags:   map[string]string{"host": "localhost"},
+			expectedFields: map[string]interface{}{"usage": 42.0, "count": int64(3)},
+			expectedTime:   time.Unix(0, 0),
+		},
+		{
+			message: "Should pass metric unchanged on invalid field",
+			source: `
+def apply(metric):
+    metric.fields["list"] = [1, 2]
+    return metric
+`,
+			expectedName:   "cpu",
+			expectedTags:   map[string]string{"host": "localhost"},
+			expectedFields: map[string]interface{}{"usage": 42.0, "count": int64(3)},
+			expectedTime:   time.Unix(0, 0),
+		},
+	}
+
+	for _, test := range tests {
+		script := NewScript()
+		script.Source = test.source
+		require.NoError(t, script.Init(), test.message)
+
+		processed := script.Apply(createTestMetric())
+
+		require.Len(t, processed, 1, test.message)
+		assert.Equal(t, test.expectedName, processed[0].Name(), test.message)
+		assert.Equal(t, test.expectedTags, processed[0].Tags(), test.message)
+		assert.Equal(t, test.expectedFields, processed[0].Fields(), test.message)
+		assert.Equal(t, test.expectedTime, processed[0].Time(), test.message)
+	}
+}
+
+func TestApplyDrop(t *testing.T) {
+	script := NewScript()
+	script.Source = `
+def apply(metric):
+    return None
+`
+	require.NoError(t, script.Init())
+
+	processed := script.Apply(createTestMetric())
+
+	assert.Empty(t, processed, "Metric was not dropped")
+}
+
+func TestApplyEmit(t *testing.T) {
+	script := NewScript()
+	script.Source = `
+def apply(metric):
+    total = Metric("total")
+    total.fields["value"] = metric.fields["count"] * 2
+    copy = deepcopy(metric)
+    copy.tags.clear()
+    return [metric, total, copy]
+`
+	require.NoError(t, script.Init())
+
+	processed := script.Apply(createTestMetric())
+
+	require.Len(t, processed, 3)
+	assert.Equal(t, "cpu", processed[0].Name())
+	assert.Equal(t, map[string]string{"host": "localhost"}, processed[0].Tags())
+	assert.Equal(t, "total", processed[1].Name())
+	assert.Equal(t, map[string]string{}, processed[1].Tags())
+	assert.Equal(t, map[string]interface{}{"value": int64(6)}, processed[1].Fields())
+	assert.Equal(t, time.Unix(0, 0), processed[1].Time(), "Should use the time of the metric")
+	assert.Equal(t, "cpu", processed[2].Name())
+	assert.Equal(t, map[string]string{}, processed[2].Tags(), "Copy should have no tags")
+	assert.Equal(t, map[string]interface{}{"usage": 42.0, "count": int64(3)}, processed[2].Fields())
+}
+
+func TestApplyState(t *testing.T) {
+	script := NewScript()
+	script.Source = `
+def apply(metric):
+    state["count"] = state.get("count", 0) + 1
+    metric.fields["seen"] = state["count"]
+    return metric
+`
+	require.NoError(t, script.Init())
+
+	for i := 1; i <= 3; i++ {
+		processed := script.Apply(createTestMetric())
+		require.Len(t, processed, 1)
+		seen, _ := processed[0].GetField("seen")
+		assert.Equal(t, int64(i), seen)
+	}
+}
+
+func TestApplyMaxSteps(t *testing.T) {
+	script := NewScript()
+	script.MaxSteps = 1000
+	script.Source = `
+def apply(metric):
+    for i in range(1000000):
+        pass
+    metric.name = "changed"
+    return metric
+`
+	require.NoError(t, script.Init())
+
+	processed := script.Apply(createTestMetric())
+
+	require.Len(t, processed, 1)
+	assert.Equal(t, "cpu", processed[0].Name(), "Metric was changed")
+}
+
+func TestInitErrors(t *testing.T) {
+	tests := []struct {
+		message string
+		source  string
+	}{
+		{"Should reject syntax error", "def apply(metric)\n    return metric\n"},
+		{"Should require apply", "x = 1\n"},
+		{"Should require one apply argument", "def apply(a, b):\n    return a\n"},
+		{"Should reject load", "load('x.star', 'y')\ndef apply(m):\n    return m\n"},
+		{"Should reject global error", "x = 1 / 0\ndef apply(m):\n    return m\n"},
+	}
+
+	for _, test := range tests {
+		script := NewScript()
+		script.Source = test.source
+		assert.Error(t, script.Init(), test.message)
+	}
+
+	script := NewScript()
+	assert.Error(t, script.Init(), "Should require source or script")
+}
+
+func TestInitScriptFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "script")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("def apply(metric):\n    return None\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	script := NewScript()
+	script.Script = f.Name()
+	require.NoError(t, script.Init())
+
+	assert.Empty(t, script.Apply(createTestMetric()))
+}
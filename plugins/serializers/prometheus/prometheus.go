@@ -0,0 +1,313 @@
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+var invalidNameCharRE = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// help is the HELP line of the metric families, as in the prometheus_client
+// output.
+const help = "Telegraf collected metric"
+
+// Serializer writes metrics in the Prometheus text exposition format. The
+// names, labels and samples are those of the prometheus_client output.
+type Serializer struct {
+	// StringAsLabel adds the string fields as labels.
+	StringAsLabel bool
+	// ExportTimestamp writes the time of the metrics as the timestamp of the
+	// samples.
+	ExportTimestamp bool
+}
+
+func NewSerializer(stringAsLabel, exportTimestamp bool) (*Serializer, error) {
+	return &Serializer{
+		StringAsLabel:   stringAsLabel,
+		ExportTimestamp: exportTimestamp,
+	}, nil
+}
+
+// family is a metric family, with the type of its first metric.
+type family struct {
+	name      string
+	valueType telegraf.ValueType
+	// samples are the samples by labels, the last one of a series replacing
+	// the others.
+	samples map[string]*sample
+}
+
+// sample is a sample of a series. Summaries and histograms hold their
+// quantiles or buckets in values, and the others their value in value.
+type sample struct {
+	labels map[string]string
+	value  float64
+	values map[float64]float64
+	count  uint64
+	sum    float64
+	time   time.Time
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+// SerializeBatch groups the samples of the metrics into metric families,
+// written in the order of their names.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	families := make(map[string]*family)
+	for _, m := range metrics {
+		s.addMetric(families, m)
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		s.writeFamily(&buf, families[name])
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Serializer) addMetric(families map[string]*family, m telegraf.Metric) {
+	labels := make(map[string]string)
+	for k, v := range m.Tags() {
+		labels[sanitize(k)] = v
+	}
+	// Prometheus doesn't have a string value type, so convert string
+	// fields to labels if enabled.
+	if s.StringAsLabel {
+		for fn, fv := range m.Fields() {
+			if fv, ok := fv.(string); ok {
+				labels[sanitize(fn)] = fv
+			}
+		}
+	}
+
+	switch m.Type() {
+	case telegraf.Summary, telegraf.Histogram:
+		smp := &sample{
+			labels: labels,
+			values: make(map[float64]float64),
+			time:   m.Time(),
+		}
+		for fn, fv := range m.Fields() {
+			value, ok := toFloat(fv)
+			if !ok {
+				continue
+			}
+			switch fn {
+			case "sum":
+				smp.sum = value
+			case "count":
+				smp.count = uint64(value)
+			default:
+				limit, err := strconv.ParseFloat(fn, 64)
+				if err == nil {
+					smp.values[limit] = value
+				}
+			}
+		}
+		addSample(families, sanitize(m.Name()), m.Type(), smp)
+
+	default:
+		for fn, fv := range m.Fields() {
+			// Ignore string and bool fields.
+			value, ok := toFloat(fv)
+			if !ok {
+				continue
+			}
+
+			// Special handling of value field; supports passthrough from
+			// the prometheus input.
+			var name string
+			switch {
+			case m.Type() == telegraf.Counter && fn == "counter",
+				m.Type() == telegraf.Gauge && fn == "gauge",
+				fn == "value":
+				name = sanitize(m.Name())
+			default:
+				name = sanitize(fmt.Sprintf("%s_%s", m.Name(), fn))
+			}
+
+			addSample(families, name, m.Type(), &sample{
+				labels: labels,
+				value:  value,
+				time:   m.Time(),
+			})
+		}
+	}
+}
+
+func addSample(
+	families map[string]*family,
+	name string,
+	valueType telegraf.ValueType,
+	smp *sample,
+) {
+	fam, ok := families[name]
+	if ok && fam.valueType != valueType &&
+		(isDistribution(fam.valueType) || isDistribution(valueType)) {
+		// The samples of a family must have the same kind of value.
+		return
+	}
+	if !ok {
+		fam = &family{
+			name:      name,
+			valueType: valueType,
+			samples:   make(map[string]*sample),
+		}
+		families[name] = fam
+	}
+	fam.samples[labelString(smp.labels, "", "")] = smp
+}
+
+func (s *Serializer) writeFamily(buf *bytes.Buffer, fam *family) {
+	fmt.Fprintf(buf, "# HELP %s %s\n", fam.name, help)
+	fmt.Fprintf(buf, "# TYPE %s %s\n", fam.name, typeName(fam.valueType))
+
+	keys := make([]string, 0, len(fam.samples))
+	for key := range fam.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		smp := fam.samples[key]
+		switch fam.valueType {
+		case telegraf.Summary:
+			for _, q := range sortedKeys(smp.values) {
+				s.writeSample(buf, fam.name, smp, "quantile", formatFloat(q), smp.values[q])
+			}
+			s.writeSample(buf, fam.name+"_sum", smp, "", "", smp.sum)
+			s.writeSample(buf, fam.name+"_count", smp, "", "", float64(smp.count))
+		case telegraf.Histogram:
+			inf := false
+			for _, le := range sortedKeys(smp.values) {
+				inf = math.IsInf(le, 1)
+				s.writeSample(buf, fam.name+"_bucket", smp, "le", formatFloat(le), smp.values[le])
+			}
+			if !inf {
+				s.writeSample(buf, fam.name+"_bucket", smp, "le", "+Inf", float64(smp.count))
+			}
+			s.writeSample(buf, fam.name+"_sum", smp, "", "", smp.sum)
+			s.writeSample(buf, fam.name+"_count", smp, "", "", float64(smp.count))
+		default:
+			s.writeSample(buf, fam.name, smp, "", "", smp.value)
+		}
+	}
+}
+
+// writeSample writes a sample line, with the additional label if not empty.
+func (s *Serializer) writeSample(
+	buf *bytes.Buffer,
+	name string,
+	smp *sample,
+	label string,
+	labelValue string,
+	value float64,
+) {
+	buf.WriteString(name)
+	buf.WriteString(labelString(smp.labels, label, labelValue))
+	buf.WriteByte(' ')
+	buf.WriteString(formatFloat(value))
+	if s.ExportTimestamp {
+		buf.WriteByte(' ')
+		buf.WriteString(strconv.FormatInt(smp.time.UnixNano()/int64(time.Millisecond), 10))
+	}
+	buf.WriteByte('\n')
+}
+
+// labelString returns the labels sorted by name, with the additional label
+// last if not empty.
+func labelString(labels map[string]string, label string, labelValue string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(labels)+1)
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, labelValueEscaper.Replace(labels[name])))
+	}
+	if label != "" {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, label, labelValue))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// isDistribution returns true for the summaries and histograms, made of
+// several samples.
+func isDistribution(tt telegraf.ValueType) bool {
+	return tt == telegraf.Summary || tt == telegraf.Histogram
+}
+
+func sortedKeys(values map[float64]float64) []float64 {
+	keys := make([]float64, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Float64s(keys)
+	return keys
+}
+
+func sanitize(value string) string {
+	return invalidNameCharRE.ReplaceAllString(value, "_")
+}
+
+func typeName(tt telegraf.ValueType) string {
+	switch tt {
+	case telegraf.Counter:
+		return "counter"
+	case telegraf.Gauge:
+		return "gauge"
+	case telegraf.Summary:
+		return "summary"
+	case telegraf.Histogram:
+		return "histogram"
+	default:
+		return "untyped"
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,148 @@
+package prometheus
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func MustMetric(v telegraf.Metric, err error) telegraf.Metric {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func TestSerializeBatch(t *testing.T) {
+	now := time.Unix(1531209600, 0)
+	metrics := []telegraf.Metric{
+		MustMetric(metric.New("cpu",
+			map[string]string{"host": "web2", "cpu-id": "cpu0"},
+			map[string]interface{}{"usage_idle": 91.5, "state": "ok", "up": true},
+			now,
+			telegraf.Gauge,
+		)),
+		MustMetric(metric.New("cpu",
+			map[string]string{"host": "web1", "cpu-id": "cpu0"},
+			map[string]interface{}{"usage_idle": int64(42)},
+			now,
+			telegraf.Gauge,
+		)),
+		MustMetric(metric.New("http_requests_total",
+			map[string]string{"code": "200"},
+			map[string]interface{}{"counter": uint64(1027)},
+			now,
+			telegraf.Counter,
+		)),
+		MustMetric(metric.New("temperature",
+			map[string]string{"room": "a \"quoted\" room"},
+			map[string]interface{}{"value": -3.5},
+			now,
+		)),
+	}
+
+	s, err := NewSerializer(true, false)
+	require.NoError(t, err)
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	assert.Equal(t, `# HELP cpu_usage_idle Telegraf collected metric
+# TYPE cpu_usage_idle gauge
+cpu_usage_idle{cpu_id="cpu0",host="web1"} 42
+cpu_usage_idle{cpu_id="cpu0",host="web2",state="ok"} 91.5
+# HELP http_requests_total Telegraf collected metric
+# TYPE http_requests_total counter
+http_requests_total{code="200"} 1027
+# HELP temperature Telegraf collected metric
+# TYPE temperature untyped
+temperature{room="a \"quoted\" room"} -3.5
+`, string(buf))
+}
+
+func TestSerializeSummary(t *testing.T) {
+	m := MustMetric(metric.New("rpc_duration_seconds",
+		map[string]string{},
+		map[string]interface{}{
+			"0.5":   0.05,
+			"0.99":  0.1,
+			"count": 2693.0,
+			"sum":   1.7560473e+07,
+		},
+		time.Unix(1531209600, 0),
+		telegraf.Summary,
+	))
+
+	s, err := NewSerializer(true, true)
+	require.NoError(t, err)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t, `# HELP rpc_duration_seconds Telegraf collected metric
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5"} 0.05 1531209600000
+rpc_duration_seconds{quantile="0.99"} 0.1 1531209600000
+rpc_duration_seconds_sum 1.7560473e+07 1531209600000
+rpc_duration_seconds_count 2693 1531209600000
+`, string(buf))
+}
+
+func TestSerializeHistogram(t *testing.T) {
+	m := MustMetric(metric.New("request_latency",
+		map[string]string{"verb": "GET"},
+		map[string]interface{}{
+			"0.1":   int64(2),
+			"1":     int64(5),
+			"count": int64(7),
+			"sum":   3.5,
+		},
+		time.Unix(1531209600, 0),
+		telegraf.Histogram,
+	))
+
+	s, err := NewSerializer(true, false)
+	require.NoError(t, err)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	assert.Equal(t, `# HELP request_latency Telegraf collected metric
+# TYPE request_latency histogram
+request_latency_bucket{verb="GET",le="0.1"} 2
+request_latency_bucket{verb="GET",le="1"} 5
+request_latency_bucket{verb="GET",le="+Inf"} 7
+request_latency_sum{verb="GET"} 3.5
+request_latency_count{verb="GET"} 7
+`, string(buf))
+}
+
+func TestSerializeSeriesReplaced(t *testing.T) {
+	metrics := []telegraf.Metric{
+		MustMetric(metric.New("load",
+			map[string]string{},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(0, 0),
+		)),
+		MustMetric(metric.New("load",
+			map[string]string{},
+			map[string]interface{}{"value": 2.0},
+			time.Unix(10, 0),
+		)),
+		// A summary cannot be added to a family of single values.
+		MustMetric(metric.New("load",
+			map[string]string{},
+			map[string]interface{}{"count": 1.0, "sum": math.Inf(1)},
+			time.Unix(20, 0),
+			telegraf.Summary,
+		)),
+	}
+
+	s, err := NewSerializer(false, false)
+	require.NoError(t, err)
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	assert.Equal(t, `# HELP load Telegraf collected metric
+# TYPE load untyped
+load 2
+`, string(buf))
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/serializers/graphite"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/plugins/serializers/json"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
 )
 
 // SerializerOutput is an interface for output plugins that are able to
@@ -35,7 +36,7 @@ type Serializer interface {
 // Config is a struct that covers the data types needed for all serializer types,
 // and can be used to instantiate _any_ of the serializers.
 type Config struct {
-	// Dataformat can be one of: influx, graphite, or json
+	// Dataformat can be one of: influx, graphite, json, or prometheus
 	DataFormat string
 
 	// Support tags in graphite protocol
@@ -60,6 +61,12 @@ type Config struct {
 
 	// Timestamp units to use for JSON formatted output
 	TimestampUnits time.Duration
+
+	// Add string fields as labels; prometheus format only
+	PrometheusStringAsLabel bool
+
+	// Write the metric times as sample timestamps; prometheus format only
+	PrometheusExportTimestamp bool
 }
 
 // NewSerializer a Serializer interface based on the given config.
@@ -73,6 +80,9 @@ func NewSerializer(config *Config) (Serializer, error) {
 		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template, config.GraphiteTagSupport)
 	case "json":
 		serializer, err = NewJsonSerializer(config.TimestampUnits)
+	case "prometheus":
+		serializer, err = NewPrometheusSerializer(config.PrometheusStringAsLabel,
+			config.PrometheusExportTimestamp)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -83,6 +93,10 @@ func NewJsonSerializer(timestampUnits time.Duration) (Serializer, error) {
 	return json.NewSerializer(timestampUnits)
 }
 
+func NewPrometheusSerializer(stringAsLabel, exportTimestamp bool) (Serializer, error) {
+	return prometheus.NewSerializer(stringAsLabel, exportTimestamp)
+}
+
 func NewInfluxSerializerConfig(config *Config) (Serializer, error) {
 	var sort influx.FieldSortOrder
 	if config.InfluxSortFields {
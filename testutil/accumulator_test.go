@@ -0,0 +1,29 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccumulatorType(t *testing.T) {
+	var acc Accumulator
+	fields := map[string]interface{}{"value": 1.0}
+
+	acc.AddFields("fields", fields, nil)
+	acc.AddCounter("counter", fields, nil)
+	acc.AddGauge("gauge", fields, nil)
+	m, err := metric.New("summary", nil, fields, time.Now(), telegraf.Summary)
+	require.NoError(t, err)
+	acc.AddMetric(m)
+
+	require.Len(t, acc.Metrics, 4)
+	assert.Equal(t, telegraf.ValueType(0), acc.Metrics[0].Type)
+	assert.Equal(t, telegraf.Counter, acc.Metrics[1].Type)
+	assert.Equal(t, telegraf.Gauge, acc.Metrics[2].Type)
+	assert.Equal(t, telegraf.Summary, acc.Metrics[3].Type)
+}